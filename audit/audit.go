@@ -0,0 +1,272 @@
+// Package audit provides an append-only log of credential changes
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// logFileName is the name of the current audit log file
+	logFileName = "audit.jsonl"
+	// saltFileName is the name of the file holding the key hash salt
+	saltFileName = "audit.salt"
+	// maxLogSize is the size at which the audit log is rotated
+	maxLogSize = 1 << 20
+	// maxBackups is the number of rotated audit log files kept
+	maxBackups = 5
+	// saltSize is the number of random bytes in the key hash salt
+	saltSize = 32
+)
+
+// Action describes what was done to a credential target
+type Action string
+
+const (
+	// ActionActivate is recorded when a profile's credentials are written
+	ActionActivate Action = "activate"
+	// ActionDisable is recorded when credentials are removed
+	ActionDisable Action = "disable"
+	// ActionRestore is recorded when credentials are re-applied after re-enabling
+	ActionRestore Action = "restore"
+	// ActionUpdate is recorded when the active profile is written again after
+	// it was edited
+	ActionUpdate Action = "update"
+	// ActionGateway is recorded when turning the local gateway on or off
+	// rewrites the credentials
+	ActionGateway Action = "gateway"
+	// ActionWipe is recorded when credentials are removed by an emergency wipe
+	ActionWipe Action = "wipe"
+)
+
+// Entry is a single audit log record
+type Entry struct {
+	Time    time.Time `json:"time"`              // When the change was made
+	Profile string    `json:"profile"`           // Name of the affected profile
+	Target  string    `json:"target"`            // File that was written
+	Action  Action    `json:"action"`            // What was done
+	KeyHash string    `json:"keyHash,omitempty"` // Salted hash of the written key
+}
+
+// Logger appends entries to a rotating JSONL audit file
+type Logger struct {
+	mu       sync.Mutex
+	logPath  string
+	saltPath string
+	salt     []byte
+}
+
+// NewLogger creates an audit logger storing its files in dir
+func NewLogger(dir string) (*Logger, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	l := &Logger{
+		logPath:  filepath.Join(dir, logFileName),
+		saltPath: filepath.Join(dir, saltFileName),
+	}
+
+	salt, err := l.loadSalt()
+	if err != nil {
+		return nil, err
+	}
+	l.salt = salt
+
+	return l, nil
+}
+
+// loadSalt reads the salt file, creating it with random content if missing
+func (l *Logger) loadSalt() ([]byte, error) {
+	data, err := os.ReadFile(l.saltPath)
+	if err == nil {
+		salt, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse audit salt: %w", err)
+		}
+		return salt, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read audit salt: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate audit salt: %w", err)
+	}
+	if err := os.WriteFile(l.saltPath, []byte(hex.EncodeToString(salt)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write audit salt: %w", err)
+	}
+
+	return salt, nil
+}
+
+// Path returns the path of the current audit log file
+func (l *Logger) Path() string {
+	return l.logPath
+}
+
+// HashKey returns the salted hash of an API key as stored in the log
+func (l *Logger) HashKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256(append(append([]byte{}, l.salt...), apiKey...))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Record appends an entry for a credential change. The API key is only
+// used to compute the entry's salted hash and is never written itself.
+func (l *Logger) Record(action Action, profile, target, apiKey string) error {
+	entry := Entry{
+		Time:    time.Now().UTC(),
+		Profile: profile,
+		Target:  target,
+		Action:  action,
+		KeyHash: l.HashKey(apiKey),
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.rotateIfNeeded(int64(len(line))); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// rotateIfNeeded shifts the log files when the next write would exceed maxLogSize
+func (l *Logger) rotateIfNeeded(incoming int64) error {
+	info, err := os.Stat(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to check audit log: %w", err)
+	}
+	if info.Size()+incoming <= maxLogSize {
+		return nil
+	}
+
+	for i := maxBackups - 1; i >= 1; i-- {
+		from := l.backupPath(i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, l.backupPath(i+1)); err != nil {
+				return fmt.Errorf("failed to rotate audit log: %w", err)
+			}
+		}
+	}
+
+	if err := os.Rename(l.logPath, l.backupPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	return nil
+}
+
+// backupPath returns the path of the n-th rotated log file
+func (l *Logger) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", l.logPath, n)
+}
+
+// Entries returns all retained entries, oldest first
+func (l *Logger) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	paths := []string{}
+	for i := maxBackups; i >= 1; i-- {
+		paths = append(paths, l.backupPath(i))
+	}
+	paths = append(paths, l.logPath)
+
+	entries := []Entry{}
+	for _, path := range paths {
+		fileEntries, err := readEntries(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return entries, nil
+}
+
+// readEntries parses a single JSONL audit file, ignoring a missing file
+func readEntries(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	// No entry can be longer than a whole log file
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxLogSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit entry in %s: %w", filepath.Base(path), err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Targets returns the distinct target files of all retained entries, in the
+// order they were first written
+func (l *Logger) Targets() ([]string, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	targets := []string{}
+	for _, entry := range entries {
+		if entry.Target != "" && !seen[entry.Target] {
+			seen[entry.Target] = true
+			targets = append(targets, entry.Target)
+		}
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHashKey(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewLogger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "sk-ant-audit-0123456789"
+	hash := l.HashKey(key)
+	if !strings.HasPrefix(hash, "sha256:") || strings.Contains(hash, key) {
+		t.Errorf("HashKey() = %s, want a sha256 hash without the key", hash)
+	}
+	if got := l.HashKey("sk-ant-audit-other"); got == hash {
+		t.Error("HashKey() is the same for different keys")
+	}
+	if got := other.HashKey(key); got == hash {
+		t.Error("HashKey() is the same with a different salt")
+	}
+	if got := reopened.HashKey(key); got != hash {
+		t.Errorf("HashKey() after reopening = %s, want %s", got, hash)
+	}
+	if got := l.HashKey(""); got != "" {
+		t.Errorf("HashKey(\"\") = %s, want empty", got)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	l, err := NewLogger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "sk-ant-record-0123456789"
+	records := []struct {
+		action  Action
+		profile string
+		target  string
+		key     string
+	}{
+		{ActionActivate, "work", "/a/settings.json", key},
+		{ActionActivate, "home", "/b/.env", "sk-ant-home-0123456789"},
+		{ActionDisable, "home", "/a/settings.json", ""},
+		{ActionWipe, "", "/c/.zshrc", ""},
+	}
+	for _, r := range records {
+		if err := l.Record(r.action, r.profile, r.target, r.key); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(records) {
+		t.Fatalf("Entries() returned %d entries, want %d", len(entries), len(records))
+	}
+	for i, r := range records {
+		e := entries[i]
+		if e.Action != r.action || e.Profile != r.profile || e.Target != r.target || e.KeyHash != l.HashKey(r.key) {
+			t.Errorf("entry %d = %+v, want %+v", i, e, r)
+		}
+	}
+
+	targets, err := l.Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/a/settings.json", "/b/.env", "/c/.zshrc"}; !slices.Equal(targets, want) {
+		t.Errorf("Targets() = %v, want %v", targets, want)
+	}
+
+	data, err := os.ReadFile(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), key) {
+		t.Error("the audit log contains an API key")
+	}
+	if info, err := os.Stat(l.Path()); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("audit log mode = %v, want owner-only", info.Mode().Perm())
+	}
+}
+
+func TestRotation(t *testing.T) {
+	l, err := NewLogger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each entry nearly fills a file, so every record rotates the previous one
+	padding := strings.Repeat("x", maxLogSize-200)
+	const records = maxBackups + 2
+	for i := 0; i < records; i++ {
+		if err := l.Record(ActionActivate, fmt.Sprintf("p%d", i), "/"+padding, ""); err != nil {
+			t.Fatalf("Record(%d) error = %v", i, err)
+		}
+	}
+
+	for n := 1; n <= maxBackups; n++ {
+		info, err := os.Stat(l.backupPath(n))
+		if err != nil {
+			t.Fatalf("backup %d: %v", n, err)
+		}
+		if info.Size() > maxLogSize {
+			t.Errorf("backup %d is %d bytes, want at most %d", n, info.Size(), maxLogSize)
+		}
+	}
+	if _, err := os.Stat(l.backupPath(maxBackups + 1)); !os.IsNotExist(err) {
+		t.Errorf("backup %d exists, want only %d backups", maxBackups+1, maxBackups)
+	}
+
+	entries, err := l.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profiles []string
+	for _, e := range entries {
+		profiles = append(profiles, e.Profile)
+	}
+	// The oldest entry was dropped with the oldest backup
+	var want []string
+	for i := records - maxBackups - 1; i < records; i++ {
+		want = append(want, fmt.Sprintf("p%d", i))
+	}
+	if !slices.Equal(profiles, want) {
+		t.Errorf("Entries() profiles = %v, want %v", profiles, want)
+	}
+}
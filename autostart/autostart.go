@@ -9,6 +9,9 @@ type Manager interface {
 	Enable() error
 	// Disable disables auto-start for the application
 	Disable() error
+	// Target returns the executable the auto-start entry launches, or "" if
+	// auto-start is disabled
+	Target() (string, error)
 }
 
 // NewManager creates a new platform-specific auto-start manager
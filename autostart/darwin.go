@@ -4,8 +4,10 @@ package autostart
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // darwinManager implements auto-start functionality for macOS using Launch Agents
@@ -87,3 +89,26 @@ func (d *darwinManager) Disable() error {
 	}
 	return nil
 }
+
+// Target returns the first program argument of the plist file
+func (d *darwinManager) Target() (string, error) {
+	data, err := os.ReadFile(d.plistPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read plist file: %w", err)
+	}
+
+	_, args, ok := strings.Cut(string(data), "<key>ProgramArguments</key>")
+	if ok {
+		_, args, ok = strings.Cut(args, "<string>")
+	}
+	if ok {
+		args, _, ok = strings.Cut(args, "</string>")
+	}
+	if !ok {
+		return "", fmt.Errorf("plist file has no ProgramArguments entry")
+	}
+	return html.UnescapeString(args), nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // linuxManager implements auto-start functionality for Linux using desktop files
@@ -76,3 +77,21 @@ func (l *linuxManager) Disable() error {
 	}
 	return nil
 }
+
+// Target returns the Exec value of the desktop file
+func (l *linuxManager) Target() (string, error) {
+	data, err := os.ReadFile(l.desktopPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read desktop file: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Exec="); ok {
+			return strings.Trim(value, `"`), nil
+		}
+	}
+	return "", fmt.Errorf("desktop file has no Exec entry")
+}
@@ -0,0 +1,88 @@
+package autostart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// shortcutTarget extracts the local base path from the LinkInfo structure
+// of a Shell Link (.lnk) file
+func shortcutTarget(data []byte) (string, error) {
+	const (
+		headerSize           = 0x4c
+		hasLinkTargetIDList  = 0x1
+		hasLinkInfo          = 0x2
+		volumeIDAndLocalPath = 0x1
+	)
+
+	if len(data) < headerSize {
+		return "", fmt.Errorf("shortcut is truncated")
+	}
+	flags := binary.LittleEndian.Uint32(data[0x14:])
+
+	offset := headerSize
+	if flags&hasLinkTargetIDList != 0 {
+		if len(data) < offset+2 {
+			return "", fmt.Errorf("shortcut is truncated")
+		}
+		offset += 2 + int(binary.LittleEndian.Uint16(data[offset:]))
+	}
+	if flags&hasLinkInfo == 0 {
+		return "", fmt.Errorf("shortcut has no link info")
+	}
+	if len(data) < offset+0x1c {
+		return "", fmt.Errorf("shortcut is truncated")
+	}
+
+	info := data[offset:]
+	size := int(binary.LittleEndian.Uint32(info))
+	if size < 0x1c {
+		return "", fmt.Errorf("shortcut is truncated")
+	}
+	if size <= len(info) {
+		info = info[:size]
+	}
+	linkFlags, ok := uint32At(info, 0x08)
+	if !ok {
+		return "", fmt.Errorf("shortcut is truncated")
+	}
+	if linkFlags&volumeIDAndLocalPath == 0 {
+		return "", fmt.Errorf("shortcut does not point to a local file")
+	}
+
+	// Prefer the Unicode path, present when the header is at least 0x24 bytes
+	if headerLen, ok := uint32At(info, 0x04); ok && headerLen >= 0x24 && len(info) >= 0x24 {
+		if start, ok := uint32At(info, 0x1c); ok && start > 0 && int(start) < len(info) {
+			units := []uint16{}
+			for i := int(start); i+1 < len(info); i += 2 {
+				u := binary.LittleEndian.Uint16(info[i:])
+				if u == 0 {
+					break
+				}
+				units = append(units, u)
+			}
+			return string(utf16.Decode(units)), nil
+		}
+	}
+
+	start, ok := uint32At(info, 0x10)
+	if !ok || start == 0 || int(start) >= len(info) {
+		return "", fmt.Errorf("shortcut has no local path")
+	}
+	path := info[start:]
+	if end := bytes.IndexByte(path, 0); end >= 0 {
+		path = path[:end]
+	}
+	return string(path), nil
+}
+
+// uint32At reads the little-endian value at offset, reporting whether b is
+// long enough to hold it
+func uint32At(b []byte, offset int) (uint32, bool) {
+	if offset < 0 || offset+4 > len(b) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(b[offset:]), true
+}
@@ -0,0 +1,71 @@
+package autostart
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// shortcut builds a .lnk file with only a header and the given LinkInfo
+func shortcut(flags uint32, info []byte) []byte {
+	data := make([]byte, 0x4c)
+	binary.LittleEndian.PutUint32(data[0x14:], flags)
+	return append(data, info...)
+}
+
+// linkInfo builds a LinkInfo structure with a local path. A Unicode path
+// is added after the ANSI one when unicode is set.
+func linkInfo(path string, unicode bool) []byte {
+	headerSize := 0x1c
+	if unicode {
+		headerSize = 0x24
+	}
+	info := make([]byte, headerSize)
+	binary.LittleEndian.PutUint32(info[0x04:], uint32(headerSize))
+	binary.LittleEndian.PutUint32(info[0x08:], 1)
+	binary.LittleEndian.PutUint32(info[0x10:], uint32(len(info)))
+	info = append(append(info, path...), 0)
+	if unicode {
+		binary.LittleEndian.PutUint32(info[0x1c:], uint32(len(info)))
+		for _, u := range utf16.Encode([]rune(path + "\x00")) {
+			info = binary.LittleEndian.AppendUint16(info, u)
+		}
+	}
+	binary.LittleEndian.PutUint32(info, uint32(len(info)))
+	return info
+}
+
+func TestShortcutTarget(t *testing.T) {
+	const path = `C:\Tools\cc-quick-profile.exe`
+
+	truncated := make([]byte, 0x1c)
+	binary.LittleEndian.PutUint32(truncated, 4)
+
+	remote := linkInfo(path, false)
+	binary.LittleEndian.PutUint32(remote[0x08:], 2)
+
+	badOffset := linkInfo(path, false)
+	binary.LittleEndian.PutUint32(badOffset[0x10:], 0xffff)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"ansi path", shortcut(0x2, linkInfo(path, false)), path, false},
+		{"unicode path", shortcut(0x2, linkInfo(`C:\工具\app.exe`, true)), `C:\工具\app.exe`, false},
+		{"short header", make([]byte, 0x20), "", true},
+		{"no link info", shortcut(0, linkInfo(path, false)), "", true},
+		{"link info size below its header", shortcut(0x2, truncated), "", true},
+		{"link info cut off", shortcut(0x2, linkInfo(path, false)[:0x10]), "", true},
+		{"remote target", shortcut(0x2, remote), "", true},
+		{"path offset out of range", shortcut(0x2, badOffset), "", true},
+	}
+	for _, tt := range tests {
+		got, err := shortcutTarget(tt.data)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: shortcutTarget() = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -130,3 +130,15 @@ func (w *windowsManager) createShortcut() error {
 
 	return nil
 }
+
+// Target returns the local path the shortcut points to
+func (w *windowsManager) Target() (string, error) {
+	data, err := os.ReadFile(w.shortcutPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read shortcut: %w", err)
+	}
+	return shortcutTarget(data)
+}
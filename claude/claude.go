@@ -11,6 +11,12 @@ import (
 	"github.com/tidwall/sjson"
 )
 
+// Environment variable names managed in the env section of settings.json
+const (
+	EnvAuthToken = "ANTHROPIC_AUTH_TOKEN"
+	EnvBaseURL   = "ANTHROPIC_BASE_URL"
+)
+
 // Manager handles Claude settings.json file operations
 type Manager struct {
 	settingsPath string
@@ -18,14 +24,11 @@ type Manager struct {
 
 // NewManager creates a new Claude settings manager
 func NewManager() (*Manager, error) {
-	homeDir, err := os.UserHomeDir()
+	settingsPath, err := DefaultSettingsPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
 
-	claudeDir := filepath.Join(homeDir, ".claude")
-	settingsPath := filepath.Join(claudeDir, "settings.json")
-
 	m := &Manager{
 		settingsPath: settingsPath,
 	}
@@ -38,6 +41,21 @@ func NewManager() (*Manager, error) {
 	return m, nil
 }
 
+// DefaultSettingsPath returns the path of the user's Claude settings.json
+// without creating it
+func DefaultSettingsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".claude", "settings.json"), nil
+}
+
+// SettingsPath returns the path of the managed Claude settings.json file
+func (m *Manager) SettingsPath() string {
+	return m.settingsPath
+}
+
 // ensureSettingsFile creates the Claude settings file if it doesn't exist
 func (m *Manager) ensureSettingsFile() error {
 	// Create .claude directory if it doesn't exist
@@ -49,7 +67,7 @@ func (m *Manager) ensureSettingsFile() error {
 	// Check if settings file exists
 	if _, err := os.Stat(m.settingsPath); os.IsNotExist(err) {
 		// Create default settings file using embedded template
-		if err := os.WriteFile(m.settingsPath, assets.ClaudeDefaultSettings, 0644); err != nil {
+		if err := writeSettingsFile(m.settingsPath, assets.ClaudeDefaultSettings); err != nil {
 			return fmt.Errorf("failed to create default settings file: %w", err)
 		}
 	}
@@ -57,6 +75,16 @@ func (m *Manager) ensureSettingsFile() error {
 	return nil
 }
 
+// writeSettingsFile writes a settings file readable only by its owner, since
+// it holds the API key. WriteFile keeps an existing file's mode, so files
+// created earlier with wider permissions are tightened as well.
+func writeSettingsFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
 // HasAuthToken checks if ANTHROPIC_AUTH_TOKEN exists in env
 func (m *Manager) HasAuthToken() (bool, error) {
 	data, err := os.ReadFile(m.settingsPath)
@@ -64,7 +92,7 @@ func (m *Manager) HasAuthToken() (bool, error) {
 		return false, fmt.Errorf("failed to read settings file: %w", err)
 	}
 
-	token := gjson.GetBytes(data, "env.ANTHROPIC_AUTH_TOKEN")
+	token := gjson.GetBytes(data, "env."+EnvAuthToken)
 	return token.Exists(), nil
 }
 
@@ -75,7 +103,7 @@ func (m *Manager) HasBaseURL() (bool, error) {
 		return false, fmt.Errorf("failed to read settings file: %w", err)
 	}
 
-	baseURL := gjson.GetBytes(data, "env.ANTHROPIC_BASE_URL")
+	baseURL := gjson.GetBytes(data, "env."+EnvBaseURL)
 	return baseURL.Exists(), nil
 }
 
@@ -94,56 +122,139 @@ func (m *Manager) HasAuthConfig() (bool, error) {
 	return hasToken && hasURL, nil
 }
 
-// SetAuthConfig sets both ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL
-func (m *Manager) SetAuthConfig(apiKey, apiURL string) error {
+// GetAuthConfig returns the current ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL
+// values, which are empty if unset
+func (m *Manager) GetAuthConfig() (apiKey, apiURL string, err error) {
 	data, err := os.ReadFile(m.settingsPath)
 	if err != nil {
-		return fmt.Errorf("failed to read settings file: %w", err)
+		return "", "", fmt.Errorf("failed to read settings file: %w", err)
 	}
 
-	// Set ANTHROPIC_AUTH_TOKEN
-	updatedData, err := sjson.SetBytes(data, "env.ANTHROPIC_AUTH_TOKEN", apiKey)
+	apiKey, apiURL = authConfig(data)
+	return apiKey, apiURL, nil
+}
+
+// ReadAuthConfig returns the ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL
+// values of a settings file without creating it. They are empty if unset
+// or if the file does not exist.
+func ReadAuthConfig(path string) (apiKey, apiURL string, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to set auth token: %w", err)
+		return "", "", fmt.Errorf("failed to read settings file: %w", err)
 	}
 
-	// Set ANTHROPIC_BASE_URL
-	updatedData, err = sjson.SetBytes(updatedData, "env.ANTHROPIC_BASE_URL", apiURL)
+	apiKey, apiURL = authConfig(data)
+	return apiKey, apiURL, nil
+}
+
+// authConfig extracts the credentials from settings data
+func authConfig(data []byte) (apiKey, apiURL string) {
+	return gjson.GetBytes(data, "env."+EnvAuthToken).String(), gjson.GetBytes(data, "env."+EnvBaseURL).String()
+}
+
+// SetAuthConfig sets both ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL
+func (m *Manager) SetAuthConfig(apiKey, apiURL string) error {
+	data, err := os.ReadFile(m.settingsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	updatedData, err := withAuthConfig(data, apiKey, apiURL)
 	if err != nil {
-		return fmt.Errorf("failed to set base URL: %w", err)
+		return err
 	}
 
 	// Write back to file
-	if err := os.WriteFile(m.settingsPath, updatedData, 0644); err != nil {
+	if err := writeSettingsFile(m.settingsPath, updatedData); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
 
 	return nil
 }
 
+// PreviewAuthConfig returns the settings file as it is and as SetAuthConfig
+// would leave it, without writing anything
+func (m *Manager) PreviewAuthConfig(apiKey, apiURL string) (before, after []byte, err error) {
+	before, err = os.ReadFile(m.settingsPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	after, err = withAuthConfig(before, apiKey, apiURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// ReadSettings returns the raw contents of the settings file
+func (m *Manager) ReadSettings() ([]byte, error) {
+	data, err := os.ReadFile(m.settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+	return data, nil
+}
+
+// withAuthConfig returns data with both env keys set
+func withAuthConfig(data []byte, apiKey, apiURL string) ([]byte, error) {
+	// Set ANTHROPIC_AUTH_TOKEN
+	updatedData, err := sjson.SetBytes(data, "env."+EnvAuthToken, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set auth token: %w", err)
+	}
+
+	// Set ANTHROPIC_BASE_URL
+	updatedData, err = sjson.SetBytes(updatedData, "env."+EnvBaseURL, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set base URL: %w", err)
+	}
+
+	return updatedData, nil
+}
+
 // RemoveAuthConfig removes both ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL
 func (m *Manager) RemoveAuthConfig() error {
-	data, err := os.ReadFile(m.settingsPath)
+	_, err := RemoveAuthConfigFile(m.settingsPath)
+	return err
+}
+
+// RemoveAuthConfigFile removes ANTHROPIC_AUTH_TOKEN and ANTHROPIC_BASE_URL from
+// the env section of any Claude settings file, such as a project's
+// settings.local.json. It reports whether either key was present.
+func RemoveAuthConfigFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to read settings file: %w", err)
+		return false, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	// Leave files without the keys untouched, such as a project's
+	// version-controlled settings
+	found := gjson.GetBytes(data, "env."+EnvAuthToken).Exists() ||
+		gjson.GetBytes(data, "env."+EnvBaseURL).Exists()
+	if !found {
+		return false, nil
 	}
 
 	// Remove ANTHROPIC_AUTH_TOKEN
-	updatedData, err := sjson.DeleteBytes(data, "env.ANTHROPIC_AUTH_TOKEN")
+	updatedData, err := sjson.DeleteBytes(data, "env."+EnvAuthToken)
 	if err != nil {
-		return fmt.Errorf("failed to remove auth token: %w", err)
+		return false, fmt.Errorf("failed to remove auth token: %w", err)
 	}
 
 	// Remove ANTHROPIC_BASE_URL
-	updatedData, err = sjson.DeleteBytes(updatedData, "env.ANTHROPIC_BASE_URL")
+	updatedData, err = sjson.DeleteBytes(updatedData, "env."+EnvBaseURL)
 	if err != nil {
-		return fmt.Errorf("failed to remove base URL: %w", err)
+		return false, fmt.Errorf("failed to remove base URL: %w", err)
 	}
 
 	// Write back to file
-	if err := os.WriteFile(m.settingsPath, updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
+	if err := writeSettingsFile(path, updatedData); err != nil {
+		return false, fmt.Errorf("failed to write settings file: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
@@ -0,0 +1,47 @@
+package claude
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/redact"
+	"github.com/tidwall/gjson"
+)
+
+// secretEnvPattern matches env variable names that hold credentials
+var secretEnvPattern = regexp.MustCompile(`(?i)(KEY|TOKEN|SECRET|PASSWORD)`)
+
+// SecretMasker returns a replacer that masks the credential values in the
+// env sections of the given settings data wherever they appear as JSON
+// strings. Previews compare the raw values and mask the result, so a
+// changed key still shows as changed when both masked forms are equal.
+func SecretMasker(data ...[]byte) *strings.Replacer {
+	seen := map[string]bool{}
+	pairs := []string{}
+	add := func(quoted, masked string) {
+		if quoted != masked && !seen[quoted] {
+			seen[quoted] = true
+			pairs = append(pairs, quoted, masked)
+		}
+	}
+	for _, d := range data {
+		gjson.GetBytes(d, "env").ForEach(func(key, value gjson.Result) bool {
+			if value.Type != gjson.String || value.String() == "" || !secretEnvPattern.MatchString(key.String()) {
+				return true
+			}
+			masked := quote(redact.MaskKey(value.String()))
+			// The settings file and re-encoded JSON may escape differently
+			add(value.Raw, masked)
+			add(quote(value.String()), masked)
+			return true
+		})
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// quote encodes s as a JSON string
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
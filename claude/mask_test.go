@@ -0,0 +1,63 @@
+package claude
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/diff"
+)
+
+func TestSecretMaskerRotatedKey(t *testing.T) {
+	// Both keys mask to the same text, so only a diff of the raw settings
+	// shows the rotation
+	const oldKey, newKey = "sk-ant-REDACTED", "sk-ant-REDACTED"
+	before := []byte(`{"env":{"ANTHROPIC_AUTH_TOKEN":"` + oldKey + `","ANTHROPIC_BASE_URL":"https://a.example.com"}}`)
+	after := []byte(`{"env":{"ANTHROPIC_AUTH_TOKEN":"` + newKey + `","ANTHROPIC_BASE_URL":"https://a.example.com"}}`)
+	mask := SecretMasker(before, after)
+
+	unified := mask.Replace(diff.Unified("s", "s", before, after))
+	if unified == "" {
+		t.Fatal("Unified() shows no change for a rotated key")
+	}
+
+	ops, err := diff.JSONPatch(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := mask.Replace(string(data))
+	if want := `[{"op":"replace","path":"/env/ANTHROPIC_AUTH_TOKEN","value":"sk-ant-…1234"}]`; patch != want {
+		t.Errorf("masked patch = %s, want %s", patch, want)
+	}
+
+	for name, out := range map[string]string{"unified": unified, "patch": patch} {
+		if strings.Contains(out, oldKey) || strings.Contains(out, newKey) {
+			t.Errorf("%s output contains a key: %s", name, out)
+		}
+	}
+}
+
+func TestSecretMasker(t *testing.T) {
+	settings := []byte(`{"env":{"MY_API_KEY":"secret-value-0001","ESCAPED_TOKEN":"a\/b-escaped-0002","ANTHROPIC_BASE_URL":"https://keep.example.com","EMPTY_TOKEN":""}}`)
+	mask := SecretMasker(settings)
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"secret-value-0001"`, `"…0001"`},
+		{`"a\/b-escaped-0002"`, `"…0002"`}, // As written in the file
+		{`"a/b-escaped-0002"`, `"…0002"`},  // As re-encoded in a patch
+		{`"https://keep.example.com"`, `"https://keep.example.com"`},
+		{`secret-value-0001`, `secret-value-0001`}, // Only JSON strings are masked
+	}
+	for _, tt := range tests {
+		if got := mask.Replace(tt.in); got != tt.want {
+			t.Errorf("Replace(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package claude
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// cwdScanLines bounds how many lines of a session log are searched for the
+// working directory, which is recorded near the start
+const cwdScanLines = 50
+
+// maxLogLine is the longest session log line read while searching
+const maxLogLine = 16 << 20
+
+// Project is a directory Claude Code has been used in
+type Project struct {
+	Dir      string    // Working directory of the sessions
+	LastUsed time.Time // When its latest session log was written
+}
+
+// RecentProjects returns up to limit projects from the session history in
+// ~/.claude/projects, most recently used first. Directories that no longer
+// exist are left out.
+func RecentProjects(limit int) ([]Project, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	root := filepath.Join(homeDir, ".claude", "projects")
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Claude projects: %w", err)
+	}
+
+	// Folder names encode the path lossily, so the directory is taken from
+	// the cwd recorded in the newest session log
+	seen := make(map[string]bool)
+	var projects []Project
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		logPath, modified, ok := latestLog(filepath.Join(root, entry.Name()))
+		if !ok {
+			continue
+		}
+		dir := sessionDir(logPath)
+		if dir == "" || seen[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[dir] = true
+		projects = append(projects, Project{Dir: dir, LastUsed: modified})
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].LastUsed.After(projects[j].LastUsed)
+	})
+	if limit > 0 && len(projects) > limit {
+		projects = projects[:limit]
+	}
+	return projects, nil
+}
+
+// latestLog returns the most recently written session log in a project folder
+func latestLog(dir string) (path string, modified time.Time, ok bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if !ok || info.ModTime().After(modified) {
+			path, modified, ok = filepath.Join(dir, entry.Name()), info.ModTime(), true
+		}
+	}
+	return path, modified, ok
+}
+
+// sessionDir returns the first cwd recorded in a session log, or "" if none
+func sessionDir(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxLogLine)
+	for i := 0; i < cwdScanLines && scanner.Scan(); i++ {
+		if cwd := gjson.GetBytes(scanner.Bytes(), "cwd").String(); filepath.IsAbs(cwd) {
+			return cwd
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// statusLineKey is the settings.json key holding the status line command
+const statusLineKey = "statusLine"
+
+// StatusLine returns the configured status line command. exists is true if
+// any statusLine entry is present, even one that is not a command.
+func (m *Manager) StatusLine() (command string, exists bool, err error) {
+	data, err := os.ReadFile(m.settingsPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	entry := gjson.GetBytes(data, statusLineKey)
+	return entry.Get("command").String(), entry.Exists(), nil
+}
+
+// SetStatusLine configures Claude Code to run command for its status line
+func (m *Manager) SetStatusLine(command string) error {
+	data, err := os.ReadFile(m.settingsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	entry := map[string]interface{}{
+		"type":    "command",
+		"command": command,
+		"padding": 0,
+	}
+	updatedData, err := sjson.SetBytes(data, statusLineKey, entry)
+	if err != nil {
+		return fmt.Errorf("failed to set status line: %w", err)
+	}
+
+	if err := writeSettingsFile(m.settingsPath, updatedData); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveStatusLine removes the statusLine entry
+func (m *Manager) RemoveStatusLine() error {
+	data, err := os.ReadFile(m.settingsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	updatedData, err := sjson.DeleteBytes(data, statusLineKey)
+	if err != nil {
+		return fmt.Errorf("failed to remove status line: %w", err)
+	}
+
+	if err := writeSettingsFile(m.settingsPath, updatedData); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ipfans/cc-quick-profile/audit"
+	"github.com/ipfans/cc-quick-profile/config"
+)
+
+// runAudit lists the credential change audit log, oldest first
+func runAudit(c *invocation, args []string) int {
+	fs := c.newFlagSet("audit")
+	count := fs.Int("n", 0, "只显示最近的 `count` 条记录")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	// Read the log directly: creating a Manager would sync Claude settings
+	configPath, err := config.Path()
+	if err != nil {
+		return c.fail("获取配置路径失败: %v", err)
+	}
+	logger, err := audit.NewLogger(filepath.Dir(configPath))
+	if err != nil {
+		return c.fail("打开审计日志失败: %v", err)
+	}
+
+	entries, err := logger.Entries()
+	if err != nil {
+		return c.fail("读取审计日志失败: %v", err)
+	}
+	if *count > 0 && len(entries) > *count {
+		entries = entries[len(entries)-*count:]
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tPROFILE\tACTION\tTARGET\tKEY HASH")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Profile,
+			entry.Action,
+			entry.Target,
+			entry.KeyHash,
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return c.fail("输出失败: %v", err)
+	}
+
+	return ExitOK
+}
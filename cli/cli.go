@@ -0,0 +1,243 @@
+// Package cli implements the headless command-line interface
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK       = 0 // Command succeeded
+	ExitError    = 1 // Command failed
+	ExitUsage    = 2 // Invalid command line
+	ExitNotFound = 3 // Requested profile does not exist
+
+	ExitCommandNotFound = 127 // Command given to exec could not be found
+)
+
+// command describes a single CLI subcommand
+type command struct {
+	name    string                                 // Subcommand name
+	args    string                                 // Argument synopsis for usage output
+	summary string                                 // One-line description
+	run     func(c *invocation, args []string) int // Handler returning an exit code
+
+	flags       []string // Flags offered by shell completion; a trailing "=" marks a flag taking a value
+	values      []string // Fixed positional values offered by shell completion
+	profileArg  bool     // Positional argument is a profile name
+	profileFlag string   // Flag whose value is a profile name
+	hidden      bool     // Omitted from usage output and completion
+}
+
+// invocation carries the I/O streams and lazily created managers for a command.
+// stderr redacts secrets; stdout does not, as some commands print keys on purpose.
+type invocation struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	format outputFormat
+	config *config.Manager
+}
+
+// commands lists all subcommands in the order they are shown in usage output
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name: "list", args: "[-o table|json|yaml] [-show-keys]", summary: "列出所有配置", run: runList,
+			flags: outputFlags,
+		},
+		{
+			name: "current", args: "[-o table|json|yaml] [-show-keys]", summary: "显示当前活动配置", run: runCurrent,
+			flags: outputFlags,
+		},
+		{
+			name: "status", args: "[-o table|json|yaml] [-show-keys]", summary: "显示启用状态及 Claude Code 设置是否同步", run: runStatus,
+			flags: outputFlags,
+		},
+		{
+			name: "use", args: "<name> [-dry-run]", summary: "切换到指定配置", run: runUse,
+			flags: []string{"-dry-run"}, profileArg: true,
+		},
+		{
+			name: "diff", args: "<name> [-format unified|json-patch]", summary: "预览切换到指定配置时 Claude Code 设置的更改", run: runDiff,
+			flags: []string{"-format="}, profileArg: true,
+		},
+		{name: "tui", summary: "打开全屏终端配置选择器", run: runTUI},
+		{
+			name: "add", args: "<name> -url URL [-color C]", summary: "添加配置，API 密钥从标准输入读取", run: runAdd,
+			flags: []string{"-url=", "-color="},
+		},
+		{
+			name: "edit", args: "<name> [-name N] [-url URL] [-color C] [-confirm] [-key]", summary: "修改配置，-key 从标准输入读取新密钥", run: runEdit,
+			flags: []string{"-name=", "-url=", "-color=", "-confirm", "-key"}, profileArg: true,
+		},
+		{
+			name: "remove", args: "<name>", summary: "删除配置", run: runRemove,
+			profileArg: true,
+		},
+		{name: "enable", summary: "启用并写入活动配置", run: runEnable},
+		{name: "disable", summary: "禁用并移除 Claude Code 中的凭据", run: runDisable},
+		{
+			name: "env", args: "[name] [-shell S] [-unset]", summary: "输出配置的环境变量导出语句", run: runEnv,
+			flags: []string{"-shell=", "-unset"}, profileArg: true,
+		},
+		{
+			name: "exec", args: "[name | -profile name] -- command [args]", summary: "以指定配置的环境变量运行命令", run: runExec,
+			flags: []string{"-profile="}, profileFlag: "-profile",
+		},
+		{
+			name: "daemon", args: "[-log-level L] [-log-format text|json]", summary: "以无界面守护进程运行文件监视和计划切换", run: runDaemon,
+			flags: []string{"-log-level=", "-log-format="},
+		},
+		{
+			name: "gateway", args: "[on|off]", summary: "启用或禁用本地网关，切换配置后无需重启 Claude Code", run: runGateway,
+			values: []string{"on", "off"},
+		},
+		{name: "doctor", summary: "诊断配置、权限及凭据同步问题", run: runDoctor},
+		{
+			name: "audit", args: "[-n count]", summary: "列出凭据变更审计记录", run: runAudit,
+			flags: []string{"-n="},
+		},
+		{
+			name: "panic", args: "[-yes]", summary: "紧急移除所有已部署的凭据并删除全部配置", run: runPanic,
+			flags: []string{"-yes"},
+		},
+		{
+			name: "statusline", args: "[-install|-uninstall]", summary: "输出 Claude Code 状态栏内容，或安装/卸载状态栏命令", run: runStatusLine,
+			flags: []string{"-install", "-uninstall"},
+		},
+		{
+			name: "completion", args: "bash|zsh|fish", summary: "生成 shell 自动补全脚本", run: runCompletion,
+			values: []string{"bash", "zsh", "fish"},
+		},
+		{name: "help", summary: "显示帮助信息", run: runHelp},
+		{name: completeCommand, run: runComplete, hidden: true},
+	}
+}
+
+// IsCommand reports whether name is a known subcommand
+func IsCommand(name string) bool {
+	return findCommand(name) != nil
+}
+
+// findCommand returns the subcommand with the given name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Run executes the subcommand named by args[0] and returns its exit code
+func Run(args []string) int {
+	c := &invocation{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: redact.NewWriter(os.Stderr),
+		format: formatTable,
+	}
+
+	if len(args) == 0 {
+		printUsage(c.stderr)
+		return ExitUsage
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(c.stderr, "未知命令: %s\n\n", args[0])
+		printUsage(c.stderr)
+		return ExitUsage
+	}
+
+	code := cmd.run(c, args[1:])
+	c.flushWebhooks()
+	c.flushStderr()
+	return code
+}
+
+// configManager returns the shared config manager, creating it on first use
+func (c *invocation) configManager() (*config.Manager, error) {
+	if c.config == nil {
+		manager, err := config.NewManager()
+		if err != nil {
+			return nil, err
+		}
+		manager.SetErrorReporter(func(err error) {
+			fmt.Fprintf(c.stderr, "警告: %v\n", err)
+		})
+		c.config = manager
+	}
+	return c.config, nil
+}
+
+// prompt writes text without a line break to stderr, flushing it past the
+// redacting writer so it shows before input is read
+func (c *invocation) prompt(text string) {
+	fmt.Fprint(c.stderr, text)
+	c.flushStderr()
+}
+
+// flushStderr writes out an unterminated line held back by the redacting writer
+func (c *invocation) flushStderr() {
+	if f, ok := c.stderr.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
+// fail prints an error message and returns ExitError
+func (c *invocation) fail(format string, a ...interface{}) int {
+	return c.failCode(ExitError, format, a...)
+}
+
+// failCode prints an error message, as a structured error document when
+// JSON or YAML output is selected, and returns code
+func (c *invocation) failCode(code int, format string, a ...interface{}) int {
+	message := fmt.Sprintf(format, a...)
+	if c.structured() {
+		c.writeError(code, message)
+		return code
+	}
+	fmt.Fprintf(c.stderr, "错误: %s\n", message)
+	return code
+}
+
+// newFlagSet creates a flag set for a subcommand that reports errors to stderr
+func (c *invocation) newFlagSet(cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// printUsage writes the list of subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: cc-quick-profile [命令] [参数]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "不带命令运行时启动系统托盘应用。")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "命令:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	tw.Flush()
+}
+
+// runHelp prints usage information
+func runHelp(c *invocation, args []string) int {
+	printUsage(c.stdout)
+	return ExitOK
+}
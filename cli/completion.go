@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+)
+
+const (
+	// programName is the executable name completion scripts are registered for
+	programName = "cc-quick-profile"
+	// completeCommand is the hidden subcommand completion scripts call back into
+	completeCommand = "__complete"
+)
+
+// runCompletion prints a completion script for the requested shell
+func runCompletion(c *invocation, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("completion"))
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(c.stdout)
+	case "zsh":
+		writeZshCompletion(c.stdout)
+	case "fish":
+		writeFishCompletion(c.stdout)
+	default:
+		fmt.Fprintf(c.stderr, "错误: 不支持的 shell: %s\n", args[0])
+		return ExitUsage
+	}
+
+	return ExitOK
+}
+
+// runComplete prints dynamic completion candidates, one per line. It reads
+// the settings file directly so completion stays fast and side-effect free.
+func runComplete(c *invocation, args []string) int {
+	if len(args) != 1 || args[0] != "profiles" {
+		return ExitUsage
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return ExitError
+	}
+
+	for _, p := range settings.Profiles {
+		fmt.Fprintln(c.stdout, p.Name)
+	}
+	return ExitOK
+}
+
+// visibleCommands returns the commands offered by completion
+func visibleCommands() []*command {
+	visible := []*command{}
+	for _, cmd := range commands {
+		if !cmd.hidden {
+			visible = append(visible, cmd)
+		}
+	}
+	return visible
+}
+
+// flagNames returns a command's flags without the value marker
+func flagNames(cmd *command) []string {
+	names := make([]string, 0, len(cmd.flags))
+	for _, f := range cmd.flags {
+		names = append(names, strings.TrimSuffix(f, "="))
+	}
+	return names
+}
+
+// writeBashCompletion writes a bash completion script. Profile names are
+// quoted with printf %q so names containing spaces complete as one word, and
+// the word being typed is unquoted without evaluating it.
+func writeBashCompletion(w io.Writer) {
+	names := []string{}
+	for _, cmd := range visibleCommands() {
+		names = append(names, cmd.name)
+	}
+
+	fmt.Fprintf(w, `# bash completion for %[1]s
+
+_cc_quick_profile_profiles() {
+    local cur=$1 name quoted unquoted= char i
+    # Undo the quoting typed so far by hand; the word is never evaluated
+    for (( i = 0; i < ${#cur}; i++ )); do
+        char=${cur:i:1}
+        case $char in
+            \'|\") continue ;;
+            \\) (( i++ )); char=${cur:i:1} ;;
+        esac
+        unquoted+=$char
+    done
+    while IFS= read -r name; do
+        if [[ $name == "$unquoted"* ]]; then
+            printf -v quoted '%%q' "$name"
+            COMPREPLY+=("$quoted")
+        fi
+    done < <(%[1]s %[2]s profiles 2>/dev/null)
+}
+
+_cc_quick_profile() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    local prev=${COMP_WORDS[COMP_CWORD-1]}
+    COMPREPLY=()
+
+    if (( COMP_CWORD == 1 )); then
+        COMPREPLY=($(compgen -W %[3]q -- "$cur"))
+        return
+    fi
+
+    case ${COMP_WORDS[1]} in
+`, programName, completeCommand, strings.Join(names, " "))
+
+	for _, cmd := range visibleCommands() {
+		body := []string{}
+		if cmd.profileFlag != "" {
+			body = append(body, fmt.Sprintf(`if [[ $prev == %s ]]; then _cc_quick_profile_profiles "$cur"; return; fi`, cmd.profileFlag))
+		}
+		if len(cmd.flags) > 0 {
+			body = append(body, fmt.Sprintf(`if [[ $cur == -* ]]; then COMPREPLY=($(compgen -W %q -- "$cur")); return; fi`, strings.Join(flagNames(cmd), " ")))
+		}
+		if len(cmd.values) > 0 {
+			body = append(body, fmt.Sprintf(`COMPREPLY=($(compgen -W %q -- "$cur"))`, strings.Join(cmd.values, " ")))
+		}
+		if cmd.profileArg {
+			body = append(body, `_cc_quick_profile_profiles "$cur"`)
+		}
+		writeCaseBranch(w, cmd.name, body)
+	}
+
+	fmt.Fprintf(w, `    esac
+}
+
+complete -o default -F _cc_quick_profile %s
+`, programName)
+}
+
+// writeCaseBranch writes a shell case branch, skipping commands with nothing to complete
+func writeCaseBranch(w io.Writer, name string, body []string) {
+	if len(body) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "    %s)\n", name)
+	for _, line := range body {
+		fmt.Fprintf(w, "        %s\n", line)
+	}
+	fmt.Fprintln(w, "        ;;")
+}
+
+// writeZshCompletion writes a zsh completion script usable from fpath or
+// sourced directly
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, `#compdef %[1]s
+compdef _cc_quick_profile %[1]s
+
+_cc_quick_profile_profiles() {
+    local -a profiles
+    profiles=("${(@f)$(%[1]s %[2]s profiles 2>/dev/null)}")
+    compadd -a profiles
+}
+
+_cc_quick_profile() {
+    local -a commands
+    commands=(
+`, programName, completeCommand)
+
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(w, "        %s\n", shellenv.Quote(shellenv.Posix, cmd.name+":"+strings.ReplaceAll(cmd.summary, ":", `\:`)))
+	}
+
+	fmt.Fprint(w, `    )
+
+    if (( CURRENT == 2 )); then
+        _describe -t commands 'command' commands
+        return
+    fi
+
+    case ${words[2]} in
+`)
+
+	for _, cmd := range visibleCommands() {
+		body := []string{}
+		if cmd.name == "exec" {
+			// Complete the wrapped command normally after "--"
+			body = append(body,
+				`local sep=${words[(i)--]}`,
+				`if (( sep < CURRENT )); then words=("${(@)words[sep+1,-1]}"); (( CURRENT -= sep )); _normal; return; fi`,
+			)
+		}
+		if cmd.profileFlag != "" {
+			body = append(body, fmt.Sprintf(`if [[ ${words[CURRENT-1]} == %s ]]; then _cc_quick_profile_profiles; return; fi`, cmd.profileFlag))
+		}
+		if len(cmd.flags) > 0 {
+			body = append(body, fmt.Sprintf(`if [[ ${words[CURRENT]} == -* ]]; then compadd -- %s; return; fi`, strings.Join(flagNames(cmd), " ")))
+		}
+		if len(cmd.values) > 0 {
+			body = append(body, "compadd -- "+strings.Join(cmd.values, " "))
+		}
+		if cmd.profileArg {
+			body = append(body, "_cc_quick_profile_profiles")
+		}
+		writeCaseBranch(w, cmd.name, body)
+	}
+
+	fmt.Fprint(w, `    esac
+}
+
+if [[ $funcstack[1] == _cc_quick_profile ]]; then
+    _cc_quick_profile "$@"
+fi
+`)
+}
+
+// writeFishCompletion writes a fish completion script
+func writeFishCompletion(w io.Writer) {
+	profiles := fmt.Sprintf("(%s %s profiles 2>/dev/null)", programName, completeCommand)
+
+	fmt.Fprintf(w, "# fish completion for %s\n", programName)
+	fmt.Fprintf(w, "complete -c %s -f\n", programName)
+
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n",
+			programName, cmd.name, shellenv.Quote(shellenv.Fish, cmd.summary))
+	}
+
+	for _, cmd := range visibleCommands() {
+		condition := shellenv.Quote(shellenv.Fish, "__fish_seen_subcommand_from "+cmd.name)
+		for _, f := range cmd.flags {
+			name := strings.TrimPrefix(strings.TrimSuffix(f, "="), "-")
+			line := fmt.Sprintf("complete -c %s -n %s -o %s", programName, condition, name)
+			if strings.HasSuffix(f, "=") {
+				line += " -x"
+			}
+			if "-"+name == cmd.profileFlag {
+				line += " -a " + shellenv.Quote(shellenv.Fish, profiles)
+			}
+			fmt.Fprintln(w, line)
+		}
+		if len(cmd.values) > 0 {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", programName, condition, shellenv.Quote(shellenv.Fish, strings.Join(cmd.values, " ")))
+		}
+		if cmd.profileArg {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", programName, condition, shellenv.Quote(shellenv.Fish, profiles))
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"github.com/ipfans/cc-quick-profile/control"
+)
+
+// remote connects to the running tray or daemon, so changes go through the
+// single process that owns the files and its menu refreshes at once. It
+// returns nil when nothing is running and the caller writes the files itself.
+func (c *invocation) remote() *control.Client {
+	client, err := control.Dial()
+	if err != nil {
+		return nil
+	}
+	return client
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/ipfans/cc-quick-profile/daemon"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// runDaemon runs the background subsystems in the foreground, logging to stderr
+func runDaemon(c *invocation, args []string) int {
+	fs := c.newFlagSet("daemon")
+	level := fs.String("log-level", "info", "日志级别: debug, info, warn 或 error")
+	format := fs.String("log-format", "text", "日志格式: text 或 json")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(*level)); err != nil {
+		fmt.Fprintf(c.stderr, "错误: 不支持的日志级别: %s\n", *level)
+		return ExitUsage
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	output := redact.NewWriter(os.Stderr)
+
+	var handler slog.Handler
+	switch *format {
+	case "text":
+		handler = slog.NewTextHandler(output, options)
+	case "json":
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		fmt.Fprintf(c.stderr, "错误: 不支持的日志格式: %s\n", *format)
+		return ExitUsage
+	}
+
+	if err := daemon.Run(context.Background(), slog.New(handler)); err != nil {
+		return c.fail("守护进程运行失败: %v", err)
+	}
+	return ExitOK
+}
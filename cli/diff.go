@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/diff"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// Preview formats accepted by diff -format
+const (
+	diffUnified   = "unified"
+	diffJSONPatch = "json-patch"
+)
+
+// diffFormat is the flag.Value behind diff -format; it rejects unknown formats
+type diffFormat string
+
+func (f *diffFormat) String() string { return string(*f) }
+
+func (f *diffFormat) Set(s string) error {
+	if s != diffUnified && s != diffJSONPatch {
+		return fmt.Errorf("不支持的输出格式: %s", s)
+	}
+	*f = diffFormat(s)
+	return nil
+}
+
+// runDiff previews the changes activating a profile would make
+func runDiff(c *invocation, args []string) int {
+	fs := c.newFlagSet("diff")
+	format := diffFormat(diffUnified)
+	fs.Var(&format, "format", "输出格式: unified 或 json-patch")
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	positional, err := parseInterspersed(fs, args)
+	if format == diffJSONPatch {
+		// A JSON patch was asked for, so errors are reported as JSON too
+		c.format = formatJSON
+	}
+	switch {
+	case err != nil && (err == flag.ErrHelp || !c.structured()):
+		io.Copy(c.stderr, &usage)
+		return ExitUsage
+	case err != nil:
+		return c.failCode(ExitUsage, "%v", err)
+	case len(positional) != 1 && c.structured():
+		return c.failCode(ExitUsage, "用法: cc-quick-profile %s", usageLine("diff"))
+	case len(positional) != 1:
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("diff"))
+		return ExitUsage
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	return c.printPreview(manager, positional[0], string(format))
+}
+
+// printPreview prints the changes activating a profile would make to
+// Claude Code's settings, with secrets masked
+func (c *invocation) printPreview(manager *config.Manager, name, format string) int {
+	before, after, err := manager.PreviewActivation(name)
+	if err != nil {
+		return c.failProfile("生成预览失败", err)
+	}
+	if !manager.GetSettings().Enabled {
+		fmt.Fprintln(c.stderr, "注意: 当前处于禁用状态，切换配置不会修改 Claude Code 设置")
+	}
+
+	mask := claude.SecretMasker(before, after)
+
+	if format == diffJSONPatch {
+		ops, err := diff.JSONPatch(before, after)
+		if err != nil {
+			return c.fail("比较 Claude Code 设置失败: %v", err)
+		}
+		data, err := json.MarshalIndent(ops, "", "  ")
+		if err != nil {
+			return c.fail("输出失败: %v", err)
+		}
+		fmt.Fprintln(c.stdout, redact.String(mask.Replace(string(data))))
+		return ExitOK
+	}
+
+	path := manager.ClaudeSettingsPath()
+	text := diff.Unified(path, path, before, after)
+	if text == "" {
+		fmt.Fprintln(c.stderr, "Claude Code 设置无需更改")
+		return ExitOK
+	}
+	fmt.Fprint(c.stdout, redact.String(mask.Replace(text)))
+	return ExitOK
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ipfans/cc-quick-profile/doctor"
+)
+
+// statusMarks prefixes each check result by its status
+var statusMarks = map[doctor.Status]string{
+	doctor.StatusOK:      "✓",
+	doctor.StatusWarning: "!",
+	doctor.StatusError:   "✗",
+}
+
+// runDoctor diagnoses configuration problems, printing a remediation hint
+// for each and returning ExitError if any were found
+func runDoctor(c *invocation, args []string) int {
+	fs := c.newFlagSet("doctor")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	problems := 0
+	for _, check := range doctor.Run() {
+		fmt.Fprintf(c.stdout, "%s %s: %s\n", statusMarks[check.Status], check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Fprintf(c.stdout, "    建议: %s\n", check.Hint)
+		}
+		if check.Problem() {
+			problems++
+		}
+	}
+
+	fmt.Fprintln(c.stdout)
+	if problems > 0 {
+		fmt.Fprintf(c.stdout, "发现 %d 个问题\n", problems)
+		return ExitError
+	}
+	fmt.Fprintln(c.stdout, "未发现问题")
+	return ExitOK
+}
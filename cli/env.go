@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+)
+
+// runEnv prints shell statements exporting a profile's environment. It only
+// reads the settings file, leaving Claude Code's settings untouched.
+func runEnv(c *invocation, args []string) int {
+	fs := c.newFlagSet("env")
+	shell := fs.String("shell", "", "输出语法: bash, zsh, fish, powershell 或 dotenv (默认根据 $SHELL 检测)")
+	unset := fs.Bool("unset", false, "输出取消设置变量的语句")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 1 {
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("env"))
+		return ExitUsage
+	}
+
+	dialect := defaultDialect()
+	if *shell != "" {
+		if dialect, err = shellenv.ParseDialect(*shell); err != nil {
+			fmt.Fprintf(c.stderr, "错误: %v\n", err)
+			return ExitUsage
+		}
+	}
+
+	if *unset {
+		fmt.Fprint(c.stdout, shellenv.RenderUnset(dialect, shellenv.Vars(models.Profile{})))
+		return ExitOK
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return c.fail("读取配置失败: %v", err)
+	}
+
+	profile, code := c.resolveProfile(settings, positional)
+	if code != ExitOK {
+		return code
+	}
+
+	fmt.Fprint(c.stdout, shellenv.Render(dialect, shellenv.Vars(*profile)))
+	return ExitOK
+}
+
+// resolveProfile returns the profile named by the optional positional
+// argument, or the active profile when no name is given
+func (c *invocation) resolveProfile(settings *models.Settings, positional []string) (*models.Profile, int) {
+	if len(positional) == 0 {
+		active := settings.GetActiveProfile()
+		if active == nil {
+			return nil, c.failCode(ExitNotFound, "当前没有活动配置，请指定配置名称")
+		}
+		return active, ExitOK
+	}
+
+	profile := settings.FindProfile(positional[0])
+	if profile == nil {
+		return nil, c.failCode(ExitNotFound, "%v: '%s'", config.ErrProfileNotFound, positional[0])
+	}
+	return profile, ExitOK
+}
+
+// defaultDialect picks the output syntax from the user's shell
+func defaultDialect() shellenv.Dialect {
+	shell := os.Getenv("SHELL")
+	if shell == "" && runtime.GOOS == "windows" {
+		return shellenv.PowerShell
+	}
+	return shellenv.DetectDialect(shell)
+}
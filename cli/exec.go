@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+)
+
+// runExec starts a command with a profile's environment injected, forwarding
+// stdio and signals and returning the child's exit code. Claude Code's
+// settings file is never written, so each terminal can use its own account.
+func runExec(c *invocation, args []string) int {
+	fs := c.newFlagSet("exec")
+	profileName := fs.String("profile", "", "使用的配置 `名称` (默认使用活动配置)")
+
+	// The profile may also be named before "--"; everything after it is the
+	// command, whose own flags are left alone
+	positional := []string{}
+	var command []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		var err error
+		if positional, err = parseInterspersed(fs, args[:i]); err != nil {
+			return ExitUsage
+		}
+		command = args[i+1:]
+	} else {
+		if err := fs.Parse(args); err != nil {
+			return ExitUsage
+		}
+		command = fs.Args()
+	}
+	if len(command) == 0 || len(positional) > 1 || (len(positional) == 1 && *profileName != "") {
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("exec"))
+		return ExitUsage
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return c.fail("读取配置失败: %v", err)
+	}
+
+	if *profileName != "" {
+		positional = append(positional, *profileName)
+	}
+	profile, code := c.resolveProfile(settings, positional)
+	if code != ExitOK {
+		return code
+	}
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Env = mergeEnv(os.Environ(), shellenv.Vars(*profile))
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// Start listening before the child exists so no signal is lost
+	signals := make(chan os.Signal, 1)
+	if len(forwardedSignals) > 0 {
+		signal.Notify(signals, forwardedSignals...)
+		defer signal.Stop(signals)
+	}
+
+	// Survive terminal signals while the child handles them. They are caught
+	// rather than ignored, as ignored signals would stay ignored in the child.
+	terminal := make(chan os.Signal, 1)
+	signal.Notify(terminal, terminalSignals...)
+	defer signal.Stop(terminal)
+
+	if err := child.Start(); err != nil {
+		c.fail("启动命令失败: %v", err)
+		if errors.Is(err, exec.ErrNotFound) {
+			return ExitCommandNotFound
+		}
+		return ExitError
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := child.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitCode(exitErr)
+		}
+		return c.fail("等待命令结束失败: %v", err)
+	}
+
+	return ExitOK
+}
+
+// mergeEnv returns environ with vars added, replacing existing assignments
+func mergeEnv(environ []string, vars []shellenv.Var) []string {
+	merged := make([]string, 0, len(environ)+len(vars))
+	for _, kv := range environ {
+		replaced := false
+		for _, v := range vars {
+			if strings.HasPrefix(kv, v.Name+"=") {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, kv)
+		}
+	}
+
+	for _, v := range vars {
+		merged = append(merged, v.Name+"="+v.Value)
+	}
+	return merged
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are relayed from this process to the child started by
+// exec. They only reach the child this way when sent to this process's PID.
+var forwardedSignals = []os.Signal{
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// terminalSignals are generated by the terminal for its whole foreground
+// process group, so the child already receives them directly
+var terminalSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+}
+
+// exitCode converts a child's exit status to a shell-style exit code, using
+// 128 plus the signal number when the child was killed by a signal
+func exitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are relayed from this process to the child started by
+// exec. Windows cannot send signals to other processes.
+var forwardedSignals = []os.Signal{}
+
+// terminalSignals are delivered by the console to every attached process,
+// so the child already receives them directly
+var terminalSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// exitCode returns the child's exit code
+func exitCode(exitErr *exec.ExitError) int {
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ipfans/cc-quick-profile/control"
+)
+
+// runGateway turns the local gateway on or off, or shows its state
+func runGateway(c *invocation, args []string) int {
+	fs := c.newFlagSet("gateway")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 1 && fs.Arg(0) != "on" && fs.Arg(0) != "off") {
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("gateway"))
+		return ExitUsage
+	}
+
+	var gateway control.Gateway
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		params := control.GatewayParams{}
+		if fs.NArg() == 1 {
+			enabled := fs.Arg(0) == "on"
+			params.Enabled = &enabled
+		}
+		if err := client.Call(control.MethodGateway, params, &gateway); err != nil {
+			return c.fail("更新本地网关失败: %v", err)
+		}
+	} else {
+		manager, err := c.configManager()
+		if err != nil {
+			return c.fail("初始化配置管理器失败: %v", err)
+		}
+
+		if fs.NArg() == 1 {
+			if err := manager.SetGatewayEnabled(fs.Arg(0) == "on"); err != nil {
+				return c.fail("更新本地网关失败: %v", err)
+			}
+		}
+
+		settings := manager.GetSettings().Gateway
+		gateway = control.Gateway{Enabled: settings.Enabled, URL: settings.URL()}
+	}
+
+	if !gateway.Enabled {
+		fmt.Fprintln(c.stdout, "本地网关: 未启用")
+		return ExitOK
+	}
+	fmt.Fprintf(c.stdout, "本地网关: %s\n", gateway.URL)
+	fmt.Fprintln(c.stdout, "托盘或守护进程运行时提供网关服务")
+	return ExitOK
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the structured output documents. It is
+// incremented whenever a field is removed or changes meaning.
+const SchemaVersion = 1
+
+// outputFormat selects how read commands print their results
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatYAML  outputFormat = "yaml"
+)
+
+// profileDocument is the structured form of a profile
+type profileDocument struct {
+	Name   string `json:"name" yaml:"name"`
+	APIURL string `json:"apiUrl" yaml:"apiUrl"`
+	APIKey string `json:"apiKey" yaml:"apiKey"` // Masked unless -show-keys is given
+	Active bool   `json:"active" yaml:"active"`
+	Color  string `json:"color" yaml:"color"`
+}
+
+// listDocument is the structured output of the list command
+type listDocument struct {
+	SchemaVersion int               `json:"schemaVersion" yaml:"schemaVersion"`
+	Profiles      []profileDocument `json:"profiles" yaml:"profiles"`
+}
+
+// currentDocument is the structured output of the current command
+type currentDocument struct {
+	SchemaVersion int              `json:"schemaVersion" yaml:"schemaVersion"`
+	Profile       *profileDocument `json:"profile" yaml:"profile"` // Null if no profile is active
+}
+
+// statusDocument is the structured output of the status command
+type statusDocument struct {
+	SchemaVersion int                  `json:"schemaVersion" yaml:"schemaVersion"`
+	Enabled       bool                 `json:"enabled" yaml:"enabled"`
+	AutoStart     bool                 `json:"autoStart" yaml:"autoStart"`
+	ActiveProfile *string              `json:"activeProfile" yaml:"activeProfile"`
+	ProfileCount  int                  `json:"profileCount" yaml:"profileCount"`
+	Claude        claudeStatusDocument `json:"claude" yaml:"claude"`
+}
+
+// claudeStatusDocument describes the state of Claude Code's settings file
+type claudeStatusDocument struct {
+	SettingsPath string `json:"settingsPath" yaml:"settingsPath"`
+	Configured   bool   `json:"configured" yaml:"configured"` // Both env keys are present
+	InSync       bool   `json:"inSync" yaml:"inSync"`         // Env keys match the active profile
+	BaseURL      string `json:"baseUrl" yaml:"baseUrl"`
+}
+
+// errorDocument is printed instead of a result when a command fails with
+// structured output selected
+type errorDocument struct {
+	SchemaVersion int         `json:"schemaVersion" yaml:"schemaVersion"`
+	Error         errorDetail `json:"error" yaml:"error"`
+}
+
+// errorDetail carries the exit code and message of a failed command
+type errorDetail struct {
+	Code    int    `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// outputFlags are the completion entries for the flags added by addOutputFlags
+var outputFlags = []string{"-output=", "-o=", "-show-keys"}
+
+// outputOptions holds the flags shared by read commands
+type outputOptions struct {
+	format   formatValue
+	showKeys bool
+}
+
+// formatValue is the flag.Value behind -output; it rejects unknown formats
+// and keeps the last accepted one
+type formatValue outputFormat
+
+func (f *formatValue) String() string { return string(*f) }
+
+func (f *formatValue) Set(s string) error {
+	switch v := outputFormat(s); v {
+	case formatTable, formatJSON, formatYAML:
+		*f = formatValue(v)
+		return nil
+	}
+	return fmt.Errorf("不支持的输出格式: %s", s)
+}
+
+// addOutputFlags registers -output (-o) and -show-keys on a flag set
+func addOutputFlags(fs *flag.FlagSet) *outputOptions {
+	opts := &outputOptions{format: formatValue(formatTable)}
+	fs.Var(&opts.format, "output", "输出格式: table, json 或 yaml")
+	fs.Var(&opts.format, "o", "-output 的简写")
+	fs.BoolVar(&opts.showKeys, "show-keys", false, "显示完整的 API 密钥")
+	return opts
+}
+
+// parseOutput parses a read command's flags and makes the chosen format the
+// invocation's format. Once a structured format has been accepted, a later
+// parse error is printed as an error document as well.
+func (c *invocation) parseOutput(fs *flag.FlagSet, opts *outputOptions, args []string) bool {
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	err := fs.Parse(args)
+	c.format = outputFormat(opts.format)
+	if err == nil {
+		return true
+	}
+	if c.structured() && err != flag.ErrHelp {
+		c.writeError(ExitUsage, err.Error())
+		return false
+	}
+	io.Copy(c.stderr, &usage)
+	return false
+}
+
+// structured reports whether a machine-readable format is selected
+func (c *invocation) structured() bool {
+	return c.format == formatJSON || c.format == formatYAML
+}
+
+// writeDocument prints v in the selected structured format
+func (c *invocation) writeDocument(v interface{}) error {
+	if c.format == formatYAML {
+		enc := yaml.NewEncoder(c.stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeError prints a failure as a structured error document
+func (c *invocation) writeError(code int, message string) {
+	doc := errorDocument{
+		SchemaVersion: SchemaVersion,
+		Error:         errorDetail{Code: code, Message: redact.String(message)},
+	}
+	if err := c.writeDocument(doc); err != nil {
+		fmt.Fprintf(c.stderr, "错误: %s\n", doc.Error.Message)
+	}
+}
+
+// maskURL removes credentials embedded in a URL unless showKeys is set
+func maskURL(apiURL string, showKeys bool) string {
+	if showKeys {
+		return apiURL
+	}
+	return redact.String(apiURL)
+}
+
+// newProfileDocument converts a profile, masking its key unless showKeys is set
+func newProfileDocument(p models.Profile, showKeys bool) profileDocument {
+	key := redact.MaskKey(p.APIKey)
+	if showKeys {
+		key = p.APIKey
+	}
+	return profileDocument{
+		Name:   p.Name,
+		APIURL: maskURL(p.APIURL, showKeys),
+		APIKey: key,
+		Active: p.Active,
+		Color:  p.DisplayColor(),
+	}
+}
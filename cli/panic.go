@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/control"
+)
+
+// runPanic wipes every deployed credential and erases the profile store
+func runPanic(c *invocation, args []string) int {
+	fs := c.newFlagSet("panic")
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if !*yes {
+		c.prompt("此操作将移除所有已部署的凭据并删除全部配置。输入 yes 继续: ")
+		answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Fprintln(c.stderr, "已取消")
+			return ExitError
+		}
+	}
+
+	results, code := c.wipe()
+	if code != ExitOK {
+		return code
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(c.stdout, "✗ %s: %s\n", result.Path, result.Error)
+			code = ExitError
+			continue
+		}
+		fmt.Fprintf(c.stdout, "✓ %s: %s\n", result.Path, result.Detail)
+	}
+
+	return code
+}
+
+// wipe erases the credentials through the running app when there is one, so
+// it does not write them back from memory afterwards
+func (c *invocation) wipe() ([]control.WipeResult, int) {
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		var results []control.WipeResult
+		if err := client.Call(control.MethodWipe, nil, &results); err != nil {
+			return nil, c.fail("清除凭据失败: %v", err)
+		}
+		return results, ExitOK
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return nil, c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	return control.NewWipeResults(manager.WipeCredentials()), ExitOK
+}
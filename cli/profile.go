@@ -0,0 +1,502 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/control"
+	"github.com/ipfans/cc-quick-profile/models"
+	"golang.org/x/term"
+)
+
+// runList prints all profiles with masked keys
+func runList(c *invocation, args []string) int {
+	fs := c.newFlagSet("list")
+	opts := addOutputFlags(fs)
+	if !c.parseOutput(fs, opts, args) {
+		return ExitUsage
+	}
+
+	profiles, code := c.profileDocuments(opts.showKeys)
+	if code != ExitOK {
+		return code
+	}
+
+	if c.structured() {
+		if err := c.writeDocument(listDocument{SchemaVersion: SchemaVersion, Profiles: profiles}); err != nil {
+			return c.fail("输出失败: %v", err)
+		}
+		return ExitOK
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTIVE\tNAME\tAPI URL\tAPI KEY")
+	for _, doc := range profiles {
+		marker := ""
+		if doc.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, doc.Name, doc.APIURL, doc.APIKey)
+	}
+	if err := w.Flush(); err != nil {
+		return c.fail("输出失败: %v", err)
+	}
+
+	return ExitOK
+}
+
+// runCurrent prints the active profile
+func runCurrent(c *invocation, args []string) int {
+	fs := c.newFlagSet("current")
+	opts := addOutputFlags(fs)
+	if !c.parseOutput(fs, opts, args) {
+		return ExitUsage
+	}
+
+	profiles, code := c.profileDocuments(opts.showKeys)
+	if code != ExitOK {
+		return code
+	}
+
+	var active *profileDocument
+	for i := range profiles {
+		if profiles[i].Active {
+			active = &profiles[i]
+			break
+		}
+	}
+
+	if c.structured() {
+		doc := currentDocument{SchemaVersion: SchemaVersion, Profile: active}
+		if err := c.writeDocument(doc); err != nil {
+			return c.fail("输出失败: %v", err)
+		}
+		if active == nil {
+			return ExitNotFound
+		}
+		return ExitOK
+	}
+
+	if active == nil {
+		fmt.Fprintln(c.stderr, "当前没有活动配置")
+		return ExitNotFound
+	}
+
+	fmt.Fprintln(c.stdout, active.Name)
+	return ExitOK
+}
+
+// profileDocuments returns every profile, asking the running app when there
+// is one. Keys never leave the app, so showKeys reads the settings file.
+func (c *invocation) profileDocuments(showKeys bool) ([]profileDocument, int) {
+	profiles := []profileDocument{}
+
+	if !showKeys {
+		if client := c.remote(); client != nil {
+			defer client.Close()
+
+			var remote []control.Profile
+			if err := client.Call(control.MethodList, nil, &remote); err != nil {
+				return nil, c.fail("读取配置失败: %v", err)
+			}
+			for _, p := range remote {
+				profiles = append(profiles, profileDocument{Name: p.Name, APIURL: p.APIURL, APIKey: p.APIKey, Active: p.Active, Color: p.Color})
+			}
+			return profiles, ExitOK
+		}
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return nil, c.fail("读取配置失败: %v", err)
+	}
+	for _, p := range settings.Profiles {
+		profiles = append(profiles, newProfileDocument(p, showKeys))
+	}
+	return profiles, ExitOK
+}
+
+// runUse activates the named profile
+func runUse(c *invocation, args []string) int {
+	fs := c.newFlagSet("use")
+	dryRun := fs.Bool("dry-run", false, "只显示 Claude Code 设置将发生的更改，不实际切换")
+	name, code := c.parseName(fs, args)
+	if code != ExitOK {
+		return code
+	}
+
+	if !*dryRun {
+		if client := c.remote(); client != nil {
+			defer client.Close()
+
+			var status control.Status
+			if err := client.Call(control.MethodActivate, control.ActivateParams{Name: name}, &status); err != nil {
+				return c.failProfile("设置活动配置失败", err)
+			}
+			if status.Warning != "" {
+				fmt.Fprintf(c.stderr, "警告: %s\n", status.Warning)
+			}
+			c.reportActivated(name, status.Enabled)
+			return ExitOK
+		}
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	if *dryRun {
+		return c.printPreview(manager, name, diffUnified)
+	}
+
+	if err := manager.SetActiveProfile(name); err != nil && !c.postHookFailed(err) {
+		return c.failProfile("设置活动配置失败", err)
+	}
+
+	c.reportActivated(name, manager.GetSettings().Enabled)
+	return ExitOK
+}
+
+// reportActivated confirms a profile switch, warning when it was not
+// written to Claude Code because the app is disabled
+func (c *invocation) reportActivated(name string, enabled bool) {
+	fmt.Fprintf(c.stdout, "已切换到配置: %s\n", name)
+	if !enabled {
+		fmt.Fprintln(c.stderr, "注意: 当前处于禁用状态，Claude Code 设置未更新")
+	}
+}
+
+// postHookFailed prints a warning and returns true if err only reports a
+// failed post-switch hook, which leaves the switch in place
+func (c *invocation) postHookFailed(err error) bool {
+	if !errors.Is(err, config.ErrPostSwitchHook) {
+		return false
+	}
+	fmt.Fprintf(c.stderr, "警告: %v\n", err)
+	return true
+}
+
+// runAdd creates a new profile, reading its API key from stdin
+func runAdd(c *invocation, args []string) int {
+	fs := c.newFlagSet("add")
+	apiURL := fs.String("url", "", "API 端点 `URL`")
+	color := fs.String("color", "", "状态栏中显示的颜色 (默认根据名称选择)")
+	name, code := c.parseName(fs, args)
+	if code != ExitOK {
+		return code
+	}
+
+	apiKey, err := c.readKey()
+	if err != nil {
+		return c.fail("读取 API 密钥失败: %v", err)
+	}
+
+	profile := models.Profile{
+		Name:   name,
+		APIURL: strings.TrimSpace(*apiURL),
+		APIKey: apiKey,
+		Color:  *color,
+	}
+	if err := profile.Validate(); err != nil {
+		fmt.Fprintf(c.stderr, "错误: %v\n", err)
+		return ExitUsage
+	}
+
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		params := control.AddParams{Name: profile.Name, APIURL: profile.APIURL, APIKey: profile.APIKey, Color: profile.Color}
+		if err := client.Call(control.MethodAdd, params, nil); err != nil {
+			return c.failProfile("添加配置失败", err)
+		}
+		fmt.Fprintf(c.stdout, "配置 '%s' 已成功添加\n", name)
+		return ExitOK
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	if err := manager.AddProfile(profile); err != nil {
+		return c.failProfile("添加配置失败", err)
+	}
+
+	fmt.Fprintf(c.stdout, "配置 '%s' 已成功添加\n", name)
+	return ExitOK
+}
+
+// runEdit changes the name, URL or key of an existing profile
+func runEdit(c *invocation, args []string) int {
+	fs := c.newFlagSet("edit")
+	newName := fs.String("name", "", "新的配置 `名称`")
+	apiURL := fs.String("url", "", "新的 API 端点 `URL`")
+	color := fs.String("color", "", "状态栏中显示的颜色")
+	confirm := fs.Bool("confirm", false, "在托盘中切换到此配置前预览更改 (-confirm=false 关闭)")
+	readKey := fs.Bool("key", false, "从标准输入读取新的 API 密钥")
+	name, code := c.parseName(fs, args)
+	if code != ExitOK {
+		return code
+	}
+
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		params := control.EditParams{Name: name}
+		if *newName != "" {
+			params.NewName = newName
+		}
+		if *apiURL != "" {
+			params.APIURL = apiURL
+		}
+		if *color != "" {
+			params.Color = color
+		}
+		if isFlagSet(fs, "confirm") {
+			params.ConfirmSwitch = confirm
+		}
+		if *readKey {
+			key, err := c.readKey()
+			if err != nil {
+				return c.fail("读取 API 密钥失败: %v", err)
+			}
+			params.APIKey = &key
+		}
+		return c.editRemote(client, params)
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	existing := manager.GetSettings().FindProfile(name)
+	if existing == nil {
+		return c.failProfile("编辑配置失败", fmt.Errorf("%w: '%s'", config.ErrProfileNotFound, name))
+	}
+
+	updated := *existing
+	if *newName != "" {
+		updated.Name = strings.TrimSpace(*newName)
+	}
+	if *apiURL != "" {
+		updated.APIURL = strings.TrimSpace(*apiURL)
+	}
+	if *color != "" {
+		updated.Color = *color
+	}
+	if isFlagSet(fs, "confirm") {
+		updated.ConfirmSwitch = *confirm
+	}
+	if *readKey {
+		if updated.APIKey, err = c.readKey(); err != nil {
+			return c.fail("读取 API 密钥失败: %v", err)
+		}
+	}
+	if err := updated.Validate(); err != nil {
+		fmt.Fprintf(c.stderr, "错误: %v\n", err)
+		return ExitUsage
+	}
+	if updated.Name != name && manager.GetSettings().FindProfile(updated.Name) != nil {
+		return c.failProfile("编辑配置失败", fmt.Errorf("%w: '%s'", config.ErrProfileExists, updated.Name))
+	}
+
+	if err := manager.UpdateProfile(name, updated); err != nil {
+		return c.failProfile("编辑配置失败", err)
+	}
+
+	// Rewrite the active profile so Claude Code picks up the new values
+	if updated.Active {
+		if err := manager.RefreshCredentials(); err != nil {
+			return c.fail("更新 Claude Code 设置失败: %v", err)
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "配置 '%s' 已更新\n", updated.Name)
+	return ExitOK
+}
+
+// editRemote asks the running app to apply the changes made by edit
+func (c *invocation) editRemote(client *control.Client, params control.EditParams) int {
+	if err := client.Call(control.MethodEdit, params, nil); err != nil {
+		var rpcErr *control.Error
+		if errors.As(err, &rpcErr) && rpcErr.Code == control.CodeInvalidParams {
+			fmt.Fprintf(c.stderr, "错误: %v\n", err)
+			return ExitUsage
+		}
+		return c.failProfile("编辑配置失败", err)
+	}
+
+	updated := params.Name
+	if params.NewName != nil {
+		updated = strings.TrimSpace(*params.NewName)
+	}
+	fmt.Fprintf(c.stdout, "配置 '%s' 已更新\n", updated)
+	return ExitOK
+}
+
+// runRemove deletes the named profile
+func runRemove(c *invocation, args []string) int {
+	fs := c.newFlagSet("remove")
+	name, code := c.parseName(fs, args)
+	if code != ExitOK {
+		return code
+	}
+
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		if err := client.Call(control.MethodRemove, control.RemoveParams{Name: name}, nil); err != nil {
+			return c.failProfile("删除配置失败", err)
+		}
+		fmt.Fprintf(c.stdout, "配置 '%s' 已删除\n", name)
+		return ExitOK
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	if err := manager.DeleteProfile(name); err != nil {
+		return c.failProfile("删除配置失败", err)
+	}
+
+	fmt.Fprintf(c.stdout, "配置 '%s' 已删除\n", name)
+	return ExitOK
+}
+
+// runEnable turns on the global enabled state
+func runEnable(c *invocation, args []string) int {
+	return c.setEnabled("enable", args, true)
+}
+
+// runDisable turns off the global enabled state
+func runDisable(c *invocation, args []string) int {
+	return c.setEnabled("disable", args, false)
+}
+
+// setEnabled implements the enable and disable subcommands
+func (c *invocation) setEnabled(cmd string, args []string, enabled bool) int {
+	fs := c.newFlagSet(cmd)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if client := c.remote(); client != nil {
+		defer client.Close()
+
+		method := control.MethodDisable
+		if enabled {
+			method = control.MethodEnable
+		}
+		if err := client.Call(method, nil, nil); err != nil {
+			return c.fail("更新启用状态失败: %v", err)
+		}
+	} else {
+		manager, err := c.configManager()
+		if err != nil {
+			return c.fail("初始化配置管理器失败: %v", err)
+		}
+		if err := manager.SetEnabled(enabled); err != nil {
+			return c.fail("更新启用状态失败: %v", err)
+		}
+	}
+
+	if enabled {
+		fmt.Fprintln(c.stdout, "已启用")
+	} else {
+		fmt.Fprintln(c.stdout, "已禁用")
+	}
+	return ExitOK
+}
+
+// parseName parses flags that may appear before or after a single required
+// profile name argument
+func (c *invocation) parseName(fs *flag.FlagSet, args []string) (string, int) {
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return "", ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine(fs.Name()))
+		return "", ExitUsage
+	}
+	return positional[0], ExitOK
+}
+
+// parseInterspersed parses flags mixed with positional arguments and returns
+// the positional arguments in order. Everything after a "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// isFlagSet reports whether a flag was given on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// usageLine returns the synopsis of a subcommand
+func usageLine(name string) string {
+	if cmd := findCommand(name); cmd != nil && cmd.args != "" {
+		return cmd.name + " " + cmd.args
+	}
+	return name
+}
+
+// failProfile reports a profile operation error, using ExitNotFound for
+// unknown profiles
+func (c *invocation) failProfile(action string, err error) int {
+	code := ExitError
+	if errors.Is(err, config.ErrProfileNotFound) {
+		code = ExitNotFound
+	}
+	return c.failCode(code, "%s: %v", action, err)
+}
+
+// readKey reads an API key from stdin, prompting without echo on a terminal
+func (c *invocation) readKey() (string, error) {
+	if f, ok := c.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		c.prompt("API 密钥: ")
+		key, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(key)), nil
+	}
+
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		dryRun     bool
+	}{
+		{"flag first", []string{"-dry-run", "work"}, []string{"work"}, true},
+		{"flag last", []string{"work", "-dry-run"}, []string{"work"}, true},
+		{"no flags", []string{"a", "b"}, []string{"a", "b"}, false},
+		{"rest after --", []string{"work", "--", "cmd", "-x", "--", "-dry-run"}, []string{"work", "cmd", "-x", "--", "-dry-run"}, false},
+		{"flag before --", []string{"-dry-run", "--", "-x"}, []string{"-x"}, true},
+		{"only --", []string{"--"}, []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			dryRun := fs.Bool("dry-run", false, "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			if err != nil {
+				t.Fatalf("parseInterspersed(%q) error = %v", tt.args, err)
+			}
+			if !slices.Equal(positional, tt.positional) {
+				t.Errorf("parseInterspersed(%q) = %q, want %q", tt.args, positional, tt.positional)
+			}
+			if *dryRun != tt.dryRun {
+				t.Errorf("parseInterspersed(%q) -dry-run = %v, want %v", tt.args, *dryRun, tt.dryRun)
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterspersed(fs, []string{"work", "-unknown"}); err == nil {
+		t.Error("parseInterspersed() with an unknown flag error = nil")
+	}
+}
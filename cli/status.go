@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/control"
+)
+
+// runStatus prints the global state and whether Claude Code's settings
+// match the active profile
+func runStatus(c *invocation, args []string) int {
+	fs := c.newFlagSet("status")
+	opts := addOutputFlags(fs)
+	if !c.parseOutput(fs, opts, args) {
+		return ExitUsage
+	}
+
+	doc, code := c.statusDocument(opts.showKeys)
+	if code != ExitOK {
+		return code
+	}
+
+	if c.structured() {
+		if err := c.writeDocument(doc); err != nil {
+			return c.fail("输出失败: %v", err)
+		}
+		return ExitOK
+	}
+
+	activeName := "(无)"
+	if doc.ActiveProfile != nil {
+		activeName = *doc.ActiveProfile
+	}
+
+	fmt.Fprintf(c.stdout, "启用状态: %s\n", yesNo(doc.Enabled))
+	fmt.Fprintf(c.stdout, "开机自启: %s\n", yesNo(doc.AutoStart))
+	fmt.Fprintf(c.stdout, "活动配置: %s\n", activeName)
+	fmt.Fprintf(c.stdout, "配置数量: %d\n", doc.ProfileCount)
+	fmt.Fprintf(c.stdout, "Claude 设置: %s\n", doc.Claude.SettingsPath)
+	fmt.Fprintf(c.stdout, "已写入凭据: %s\n", yesNo(doc.Claude.Configured))
+	fmt.Fprintf(c.stdout, "与活动配置一致: %s\n", yesNo(doc.Claude.InSync))
+	if doc.Claude.BaseURL != "" {
+		fmt.Fprintf(c.stdout, "Base URL: %s\n", doc.Claude.BaseURL)
+	}
+
+	return ExitOK
+}
+
+// statusDocument collects the state, asking the running app when there is
+// one. The Claude Code base URL is only shown in full when read locally.
+func (c *invocation) statusDocument(showKeys bool) (statusDocument, int) {
+	doc := statusDocument{SchemaVersion: SchemaVersion}
+
+	if !showKeys {
+		if client := c.remote(); client != nil {
+			defer client.Close()
+
+			var status control.Status
+			if err := client.Call(control.MethodStatus, nil, &status); err != nil {
+				return doc, c.fail("读取状态失败: %v", err)
+			}
+			doc.Enabled = status.Enabled
+			doc.AutoStart = status.AutoStart
+			doc.ProfileCount = status.ProfileCount
+			if status.ActiveProfile != "" {
+				doc.ActiveProfile = &status.ActiveProfile
+			}
+			if status.Claude != nil {
+				doc.Claude = claudeStatusDocument{
+					SettingsPath: status.Claude.SettingsPath,
+					Configured:   status.Claude.Configured,
+					InSync:       status.Claude.InSync,
+					BaseURL:      status.Claude.BaseURL,
+				}
+			}
+			return doc, ExitOK
+		}
+	}
+
+	// Read the files directly, as creating a config manager could rewrite them
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return doc, c.fail("读取配置失败: %v", err)
+	}
+	settingsPath, err := claude.DefaultSettingsPath()
+	if err != nil {
+		return doc, c.fail("读取 Claude Code 设置失败: %v", err)
+	}
+	apiKey, apiURL, err := claude.ReadAuthConfig(settingsPath)
+	if err != nil {
+		return doc, c.fail("读取 Claude Code 设置失败: %v", err)
+	}
+
+	doc.Enabled = settings.Enabled
+	doc.AutoStart = settings.AutoStart
+	doc.ProfileCount = len(settings.Profiles)
+	if active := settings.GetActiveProfile(); active != nil {
+		doc.ActiveProfile = &active.Name
+	}
+	doc.Claude = claudeStatusDocument{
+		SettingsPath: settingsPath,
+		Configured:   apiKey != "" && apiURL != "",
+		InSync:       settings.ClaudeInSync(apiKey, apiURL),
+		BaseURL:      maskURL(apiURL, showKeys),
+	}
+	return doc, ExitOK
+}
+
+// yesNo formats a boolean for table output
+func yesNo(v bool) string {
+	if v {
+		return "是"
+	}
+	return "否"
+}
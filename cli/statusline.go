@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+	"github.com/tidwall/gjson"
+)
+
+// statusLineInputLimit caps how much of Claude Code's status JSON is read
+const statusLineInputLimit = 1 << 20
+
+// ansiColors maps profile colours to ANSI foreground codes
+var ansiColors = map[string]int{
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+}
+
+// runStatusLine prints the status line shown by Claude Code, or installs or
+// removes the statusLine entry that runs it
+func runStatusLine(c *invocation, args []string) int {
+	fs := c.newFlagSet("statusline")
+	install := fs.Bool("install", false, "在 Claude Code 设置中安装状态栏命令")
+	uninstall := fs.Bool("uninstall", false, "从 Claude Code 设置中移除状态栏命令")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	switch {
+	case *install && *uninstall:
+		fmt.Fprintf(c.stderr, "用法: cc-quick-profile %s\n", usageLine("statusline"))
+		return ExitUsage
+	case *install:
+		return c.installStatusLine()
+	case *uninstall:
+		return c.uninstallStatusLine()
+	}
+
+	return c.printStatusLine()
+}
+
+// printStatusLine renders the active profile and the model from the JSON
+// Claude Code writes to stdin. It reads the settings file directly so it
+// stays fast and never touches Claude Code's settings.
+func (c *invocation) printStatusLine() int {
+	input, _ := io.ReadAll(io.LimitReader(c.stdin, statusLineInputLimit))
+	model := gjson.GetBytes(input, "model.display_name").String()
+	if model == "" {
+		model = gjson.GetBytes(input, "model.id").String()
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		fmt.Fprintln(c.stdout, "cc-quick-profile: 读取配置失败")
+		return ExitError
+	}
+
+	var line string
+	if active := settings.GetActiveProfile(); active != nil {
+		line = fmt.Sprintf("\x1b[%dm● %s\x1b[0m", ansiColor(*active), stripControl(active.Name))
+		if !settings.Enabled {
+			line += " \x1b[2m(已禁用)\x1b[0m"
+		}
+	} else {
+		line = "\x1b[2m无活动配置\x1b[0m"
+	}
+	if model := stripControl(model); model != "" {
+		line += " \x1b[2m|\x1b[0m " + model
+	}
+
+	fmt.Fprintln(c.stdout, line)
+	return ExitOK
+}
+
+// ansiColor returns the ANSI code for a profile's colour. A colour set by
+// hand that is not one of models.ProfileColors falls back to the one
+// derived from the name.
+func ansiColor(p models.Profile) int {
+	if code, ok := ansiColors[p.DisplayColor()]; ok {
+		return code
+	}
+	p.Color = ""
+	return ansiColors[p.DisplayColor()]
+}
+
+// stripControl removes control characters, so text from the settings file
+// cannot inject escape sequences or line breaks into the prompt
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// statusLineCommand returns the command Claude Code runs for the status line
+func statusLineCommand() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return shellenv.Quote(shellenv.Posix, executable) + " statusline", nil
+}
+
+// isOwnStatusLine reports whether a statusLine command was installed by this
+// executable. Other tools such as "npx ccusage statusline" share the
+// subcommand name, so only the exact command counts.
+func isOwnStatusLine(command string) bool {
+	own, err := statusLineCommand()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(command) == own
+}
+
+// installStatusLine adds the statusLine entry unless another one exists
+func (c *invocation) installStatusLine() int {
+	manager, err := claude.NewManager()
+	if err != nil {
+		return c.fail("初始化 Claude 设置管理器失败: %v", err)
+	}
+
+	existing, exists, err := manager.StatusLine()
+	if err != nil {
+		return c.fail("读取 Claude Code 设置失败: %v", err)
+	}
+	if exists && !isOwnStatusLine(existing) {
+		return c.fail("Claude Code 已配置其他 statusLine (%s)，未作修改", existing)
+	}
+
+	command, err := statusLineCommand()
+	if err != nil {
+		return c.fail("获取程序路径失败: %v", err)
+	}
+	if err := manager.SetStatusLine(command); err != nil {
+		return c.fail("安装 statusLine 失败: %v", err)
+	}
+
+	fmt.Fprintf(c.stdout, "已在 %s 中安装 statusLine: %s\n", manager.SettingsPath(), command)
+	return ExitOK
+}
+
+// uninstallStatusLine removes the statusLine entry if we installed it
+func (c *invocation) uninstallStatusLine() int {
+	manager, err := claude.NewManager()
+	if err != nil {
+		return c.fail("初始化 Claude 设置管理器失败: %v", err)
+	}
+
+	existing, exists, err := manager.StatusLine()
+	if err != nil {
+		return c.fail("读取 Claude Code 设置失败: %v", err)
+	}
+	if !exists {
+		fmt.Fprintln(c.stdout, "未安装 statusLine")
+		return ExitOK
+	}
+	if !isOwnStatusLine(existing) {
+		return c.fail("statusLine (%s) 不是由 cc-quick-profile 安装的，未作修改", existing)
+	}
+
+	if err := manager.RemoveStatusLine(); err != nil {
+		return c.fail("移除 statusLine 失败: %v", err)
+	}
+
+	fmt.Fprintln(c.stdout, "已移除 statusLine")
+	return ExitOK
+}
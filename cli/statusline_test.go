@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestANSIColor(t *testing.T) {
+	derived := ansiColors[models.Profile{Name: "work"}.DisplayColor()]
+	tests := []struct {
+		color string
+		want  int
+	}{
+		{"blue", 34},
+		{"", derived},
+		{"purple", derived},
+	}
+	for _, tt := range tests {
+		if got := ansiColor(models.Profile{Name: "work", Color: tt.color}); got != tt.want {
+			t.Errorf("ansiColor(%q) = %d, want %d", tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestStripControl(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"work", "work"},
+		{"工作 ✓", "工作 ✓"},
+		{"evil\x1b]0;title\x07name", "evil]0;titlename"},
+		{"two\nlines\r\t", "twolines"},
+		{"c1\u009bcontrol", "c1control"},
+	}
+	for _, tt := range tests {
+		if got := stripControl(tt.in); got != tt.want {
+			t.Errorf("stripControl(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/ipfans/cc-quick-profile/tui"
+)
+
+// runTUI starts the full-screen terminal picker
+func runTUI(c *invocation, args []string) int {
+	fs := c.newFlagSet("tui")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	// The picker edits the settings in place, which a running app would
+	// overwrite from memory
+	if client := c.remote(); client != nil {
+		client.Close()
+		return c.fail("托盘或守护进程正在运行，请在托盘菜单中切换配置，或使用 use 和 edit 命令")
+	}
+
+	manager, err := c.configManager()
+	if err != nil {
+		return c.fail("初始化配置管理器失败: %v", err)
+	}
+
+	name, err := tui.Run(manager, os.Stdin, os.Stdout)
+	if errors.Is(err, tui.ErrNotTerminal) {
+		return c.failCode(ExitUsage, "tui 命令需要在交互式终端中运行")
+	}
+	if err != nil && !c.postHookFailed(err) {
+		return c.fail("终端界面运行失败: %v", err)
+	}
+
+	if name != "" {
+		c.reportActivated(name, manager.GetSettings().Enabled)
+	}
+	return ExitOK
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// webhookFlushTimeout bounds how long a command waits to deliver webhooks
+const webhookFlushTimeout = 5 * time.Second
+
+// flushWebhooks tries once to deliver the webhooks queued by the command, so
+// they are not held back until the tray or daemon runs. Failed deliveries
+// stay queued. Commands that queued nothing return at once, so read-only
+// commands never wait on an endpoint or print delivery warnings.
+func (c *invocation) flushWebhooks() {
+	if c.config == nil || c.config.WebhookQueue().Enqueued() == 0 {
+		return
+	}
+	webhooks := c.config.GetSettings().Webhooks
+	if len(webhooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookFlushTimeout)
+	defer cancel()
+	if _, err := c.config.WebhookQueue().Flush(ctx, webhooks); err != nil {
+		fmt.Fprintf(c.stderr, "警告: %v\n", err)
+	}
+}
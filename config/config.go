@@ -3,14 +3,39 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"time"
 
+	"github.com/ipfans/cc-quick-profile/audit"
 	"github.com/ipfans/cc-quick-profile/autostart"
 	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/hooks"
 	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"github.com/ipfans/cc-quick-profile/webhook"
+)
+
+// AppName is the name used for the config directory and the auto-start entry
+const AppName = "cc-quick-profile"
+
+var (
+	// ErrProfileNotFound is returned when no profile has the requested name
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrProfileExists is returned when adding a profile whose name is taken
+	ErrProfileExists = errors.New("profile already exists")
+	// ErrSwitchAborted is returned when a pre-switch hook failed and the
+	// previous profile was kept
+	ErrSwitchAborted = errors.New("switch aborted by pre-switch hook")
+	// ErrPostSwitchHook is returned when the switch succeeded but a
+	// post-switch hook failed
+	ErrPostSwitchHook = errors.New("post-switch hook failed")
 )
 
 // Manager handles loading and saving application settings
@@ -19,6 +44,10 @@ type Manager struct {
 	settings         *models.Settings
 	claudeManager    *claude.Manager
 	autostartManager autostart.Manager
+	auditLogger      *audit.Logger
+	bus              *events.Bus // Receives an event after each successful change, if set
+	webhookQueue     *webhook.Queue
+	report           func(error) // Receives failures that do not undo a saved change, if set
 }
 
 // NewManager creates a new configuration manager
@@ -40,15 +69,29 @@ func NewManager() (*Manager, error) {
 		return nil, fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	autostartManager, err := autostart.NewManager("cc-quick-profile", executablePath)
+	autostartManager, err := autostart.NewManager(AppName, executablePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize autostart manager: %w", err)
 	}
 
+	// Initialize audit logger next to the config file
+	auditLogger, err := audit.NewLogger(filepath.Dir(configPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize audit logger: %w", err)
+	}
+
+	// Initialize the webhook delivery queue next to the config file
+	webhookQueue, err := webhook.NewQueue(filepath.Join(filepath.Dir(configPath), "webhooks"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize webhook queue: %w", err)
+	}
+
 	m := &Manager{
 		configPath:       configPath,
 		claudeManager:    claudeManager,
 		autostartManager: autostartManager,
+		auditLogger:      auditLogger,
+		webhookQueue:     webhookQueue,
 	}
 
 	// Load existing config or create default
@@ -124,7 +167,7 @@ func getConfigPath() (string, error) {
 		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
-	appConfigDir := filepath.Join(configDir, "cc-quick-profile")
+	appConfigDir := filepath.Join(configDir, AppName)
 
 	// Ensure directory exists
 	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
@@ -134,22 +177,76 @@ func getConfigPath() (string, error) {
 	return filepath.Join(appConfigDir, "settings.json"), nil
 }
 
-// Load reads the configuration from disk
-func (m *Manager) Load() error {
-	data, err := os.ReadFile(m.configPath)
+// Path returns the path of the application settings file
+func Path() (string, error) {
+	return getConfigPath()
+}
+
+// LoadSettings reads the application settings without creating a Manager.
+// It has no side effects on Claude settings or autostart, which makes it
+// suitable for quick read-only uses such as shell completion. Defaults are
+// returned if no configuration has been saved yet.
+func LoadSettings() (*models.Settings, error) {
+	configPath, err := getConfigPath()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	settings, err := ReadSettings(configPath)
+	if os.IsNotExist(err) {
+		return models.NewSettings(), nil
+	}
+	return settings, err
+}
+
+// ReadSettings parses the settings file at path
+func ReadSettings(path string) (*models.Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
 	settings := &models.Settings{}
 	if err := json.Unmarshal(data, settings); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Load reads the configuration from disk
+func (m *Manager) Load() error {
+	settings, err := ReadSettings(m.configPath)
+	if err != nil {
+		return err
 	}
 
 	m.settings = settings
+	m.registerSecrets(settings.Profiles...)
+	redact.Register(settings.Gateway.Token)
+	for _, w := range settings.Webhooks {
+		redact.Register(w.Secret)
+	}
+	return nil
+}
+
+// Reload re-reads the configuration from disk and announces it as a
+// settings change
+func (m *Manager) Reload() error {
+	if err := m.Load(); err != nil {
+		return err
+	}
+	m.bus.Publish(events.SettingsChanged{})
 	return nil
 }
 
+// registerSecrets marks profile API keys for redaction in logs and errors
+func (m *Manager) registerSecrets(profiles ...models.Profile) {
+	for _, p := range profiles {
+		redact.Register(p.APIKey)
+	}
+}
+
 // Save writes the current configuration to disk
 func (m *Manager) Save() error {
 	data, err := json.MarshalIndent(m.settings, "", "  ")
@@ -157,13 +254,62 @@ func (m *Manager) Save() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
+	// The config holds API keys, so keep it private to the owner even if it
+	// was created with wider permissions
+	if err := os.WriteFile(m.configPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
+	if err := os.Chmod(m.configPath, 0600); err != nil {
+		return fmt.Errorf("failed to set config permissions: %w", err)
+	}
+
+	return nil
+}
+
+// SetEventBus publishes an event on bus after each change made through the manager
+func (m *Manager) SetEventBus(bus *events.Bus) {
+	m.bus = bus
+}
+
+// saveAndPublish saves the configuration and, if that succeeded, queues
+// event for the webhooks and publishes it
+func (m *Manager) saveAndPublish(event events.Event) error {
+	if err := m.Save(); err != nil {
+		return err
+	}
 
+	// The change is on disk, so a webhook that cannot be queued is reported
+	// on its own rather than failing it
+	if payload, ok := webhook.NewPayload(event, time.Now()); ok && len(m.settings.Webhooks) > 0 {
+		if err := m.webhookQueue.Enqueue(m.settings.Webhooks, payload); err != nil {
+			m.reportError(fmt.Errorf("failed to queue webhooks: %w", err))
+		}
+	}
+
+	m.bus.Publish(event)
 	return nil
 }
 
+// SetErrorReporter passes failures that happen after a change was saved to
+// report. Without one they are logged.
+func (m *Manager) SetErrorReporter(report func(error)) {
+	m.report = report
+}
+
+// reportError hands err to the error reporter
+func (m *Manager) reportError(err error) {
+	if m.report != nil {
+		m.report(err)
+		return
+	}
+	log.Printf("%v", redact.Error(err))
+}
+
+// WebhookQueue returns the queue of pending webhook deliveries
+func (m *Manager) WebhookQueue() *webhook.Queue {
+	return m.webhookQueue
+}
+
 // GetSettings returns the current settings
 func (m *Manager) GetSettings() *models.Settings {
 	return m.settings
@@ -174,12 +320,13 @@ func (m *Manager) AddProfile(profile models.Profile) error {
 	// Check if profile with same name already exists
 	for _, p := range m.settings.Profiles {
 		if p.Name == profile.Name {
-			return fmt.Errorf("profile with name '%s' already exists", profile.Name)
+			return fmt.Errorf("%w: '%s'", ErrProfileExists, profile.Name)
 		}
 	}
 
+	m.registerSecrets(profile)
 	m.settings.Profiles = append(m.settings.Profiles, profile)
-	return m.Save()
+	return m.saveAndPublish(events.ProfileAdded{Name: profile.Name})
 }
 
 // DeleteProfile removes a profile by name
@@ -196,16 +343,17 @@ func (m *Manager) DeleteProfile(name string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("profile '%s' not found", name)
+		return fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
 	}
 
 	m.settings.Profiles = profiles
-	return m.Save()
+	return m.saveAndPublish(events.ProfileDeleted{Name: name})
 }
 
 // UpdateProfile updates an existing profile
 func (m *Manager) UpdateProfile(name string, updated models.Profile) error {
 	found := false
+	m.registerSecrets(updated)
 
 	for i, p := range m.settings.Profiles {
 		if p.Name == name {
@@ -216,10 +364,10 @@ func (m *Manager) UpdateProfile(name string, updated models.Profile) error {
 	}
 
 	if !found {
-		return fmt.Errorf("profile '%s' not found", name)
+		return fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
 	}
 
-	return m.Save()
+	return m.saveAndPublish(events.ProfileUpdated{OldName: name, Name: updated.Name})
 }
 
 // SetEnabled sets the global enabled state and updates Claude settings
@@ -230,35 +378,217 @@ func (m *Manager) SetEnabled(enabled bool) error {
 		// If enabling and there's an active profile, apply it to Claude settings
 		activeProfile := m.settings.GetActiveProfile()
 		if activeProfile != nil {
-			if err := m.claudeManager.SetAuthConfig(activeProfile.APIKey, activeProfile.APIURL); err != nil {
-				return fmt.Errorf("failed to set Claude auth config: %w", err)
+			if err := m.writeClaudeCredentials(audit.ActionRestore, *activeProfile); err != nil {
+				return err
 			}
 		}
 	} else {
 		// If disabling, remove auth config from Claude settings
+		profileName := ""
+		if activeProfile := m.settings.GetActiveProfile(); activeProfile != nil {
+			profileName = activeProfile.Name
+		}
+		if err := m.recordAudit(audit.ActionDisable, profileName, ""); err != nil {
+			return err
+		}
 		if err := m.claudeManager.RemoveAuthConfig(); err != nil {
 			return fmt.Errorf("failed to remove Claude auth config: %w", err)
 		}
 	}
 
-	return m.Save()
+	return m.saveAndPublish(events.EnabledChanged{Enabled: enabled})
 }
 
-// SetActiveProfile activates a profile by name and updates Claude settings
+// SetActiveProfile activates a profile by name and updates Claude settings.
+// The global and the profile's pre-switch hooks run first and can abort the
+// switch; post-switch hooks run once it was saved.
 func (m *Manager) SetActiveProfile(name string) error {
+	return SwitchProfile(func(fn func(m *Manager) error) error {
+		return fn(m)
+	}, name)
+}
+
+// SwitchProfile activates a profile like SetActiveProfile, but only holds
+// the manager while reading and saving. with must run fn with exclusive
+// access to the manager; the hooks run on the calling goroutine in between,
+// so a slow hook does not block other users of the manager.
+func SwitchProfile(with func(fn func(m *Manager) error) error, name string) error {
+	var sw *profileSwitch
+	err := with(func(m *Manager) error {
+		var err error
+		sw, err = m.prepareSwitch(name)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := hooks.Run(hooks.PreSwitch, sw.preSwitch, sw.info); err != nil {
+		return fmt.Errorf("%w: %w", ErrSwitchAborted, err)
+	}
+
+	if err := with(func(m *Manager) error {
+		return m.commitSwitch(name)
+	}); err != nil {
+		return err
+	}
+
+	if err := hooks.RunAll(hooks.PostSwitch, sw.postSwitch, sw.info); err != nil {
+		return fmt.Errorf("%w: %w", ErrPostSwitchHook, err)
+	}
+	return nil
+}
+
+// profileSwitch holds copies of what the hooks of a switch need, so they
+// can run without access to the manager
+type profileSwitch struct {
+	info       hooks.Switch
+	preSwitch  []models.Hook
+	postSwitch []models.Hook
+}
+
+// prepareSwitch collects the hooks for activating the named profile
+func (m *Manager) prepareSwitch(name string) (*profileSwitch, error) {
+	profile := m.settings.FindProfile(name)
+	if profile == nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
+	}
+
+	sw := &profileSwitch{info: hooks.Switch{New: *profile}}
+	if old := m.settings.GetActiveProfile(); old != nil {
+		previous := *old
+		sw.info.Old = &previous
+	}
+	sw.preSwitch = append(slices.Clone(m.settings.Hooks.PreSwitch), profile.Hooks.PreSwitch...)
+	sw.postSwitch = append(slices.Clone(profile.Hooks.PostSwitch), m.settings.Hooks.PostSwitch...)
+	return sw, nil
+}
+
+// commitSwitch marks the named profile active and writes its credentials.
+// The profile may have been removed while the pre-switch hooks ran.
+func (m *Manager) commitSwitch(name string) error {
+	if m.settings.FindProfile(name) == nil {
+		return fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
+	}
+
 	m.settings.SetActiveProfile(name)
 
 	// If enabled, update Claude settings with the new active profile
 	if m.settings.Enabled {
 		activeProfile := m.settings.GetActiveProfile()
 		if activeProfile != nil {
-			if err := m.claudeManager.SetAuthConfig(activeProfile.APIKey, activeProfile.APIURL); err != nil {
-				return fmt.Errorf("failed to set Claude auth config: %w", err)
+			if err := m.writeClaudeCredentials(audit.ActionActivate, *activeProfile); err != nil {
+				return err
 			}
 		}
 	}
 
-	return m.Save()
+	return m.saveAndPublish(events.ProfileActivated{Name: name})
+}
+
+// PreviewActivation returns Claude Code's settings file as it is and as
+// SetActiveProfile would leave it, without writing anything. Both are the
+// same while the app is disabled.
+func (m *Manager) PreviewActivation(name string) (before, after []byte, err error) {
+	profile := m.settings.FindProfile(name)
+	if profile == nil {
+		return nil, nil, fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
+	}
+
+	if !m.settings.Enabled {
+		before, err = m.claudeManager.ReadSettings()
+		return before, before, err
+	}
+
+	return m.claudeManager.PreviewAuthConfig(m.settings.ClaudeCredentials(*profile))
+}
+
+// RefreshCredentials writes the active profile's credentials to Claude Code
+// again, such as after the profile was edited. Unlike SetActiveProfile it
+// runs no hooks and announces no switch. It does nothing while disabled.
+func (m *Manager) RefreshCredentials() error {
+	active := m.settings.GetActiveProfile()
+	if !m.settings.Enabled || active == nil {
+		return nil
+	}
+	return m.writeClaudeCredentials(audit.ActionUpdate, *active)
+}
+
+// writeClaudeCredentials points Claude Code at a profile, or at the gateway
+// while it is enabled. The audit entry is recorded first, so a failure to
+// record it leaves both Claude Code's settings and the profile store as they
+// were.
+func (m *Manager) writeClaudeCredentials(action audit.Action, profile models.Profile) error {
+	// The hash is of the key actually written, the gateway token while the
+	// gateway is enabled
+	apiKey, apiURL := m.settings.ClaudeCredentials(profile)
+	if err := m.recordAudit(action, profile.Name, apiKey); err != nil {
+		return err
+	}
+	if err := m.claudeManager.SetAuthConfig(apiKey, apiURL); err != nil {
+		return fmt.Errorf("failed to set Claude auth config: %w", err)
+	}
+	return nil
+}
+
+// SetConfirmSwitch sets whether activating a profile from the tray first
+// shows a preview of the changes
+func (m *Manager) SetConfirmSwitch(name string, confirm bool) error {
+	profile := m.settings.FindProfile(name)
+	if profile == nil {
+		return fmt.Errorf("%w: '%s'", ErrProfileNotFound, name)
+	}
+
+	profile.ConfirmSwitch = confirm
+	return m.saveAndPublish(events.SettingsChanged{})
+}
+
+// SetNotificationEnabled turns a category of tray notifications on or off
+func (m *Manager) SetNotificationEnabled(category string, enabled bool) error {
+	if !slices.Contains(models.NotificationCategories, category) {
+		return fmt.Errorf("unknown notification category: %s", category)
+	}
+
+	m.settings.SetNotificationEnabled(category, enabled)
+	return m.saveAndPublish(events.SettingsChanged{})
+}
+
+// ConfigPath returns the path of the application settings file
+func (m *Manager) ConfigPath() string {
+	return m.configPath
+}
+
+// ClaudeSettingsPath returns the path of the managed Claude settings file
+func (m *Manager) ClaudeSettingsPath() string {
+	return m.claudeManager.SettingsPath()
+}
+
+// ClaudeAuthConfig returns the API key and URL currently set in Claude settings
+func (m *Manager) ClaudeAuthConfig() (apiKey, apiURL string, err error) {
+	return m.claudeManager.GetAuthConfig()
+}
+
+// ClaudeInSync reports whether Claude Code's credentials are the ones the
+// enabled state and active profile call for
+func (m *Manager) ClaudeInSync() (bool, error) {
+	apiKey, apiURL, err := m.ClaudeAuthConfig()
+	if err != nil {
+		return false, err
+	}
+	return m.settings.ClaudeInSync(apiKey, apiURL), nil
+}
+
+// recordAudit appends a credential change on the Claude settings file to the audit log
+func (m *Manager) recordAudit(action audit.Action, profile, apiKey string) error {
+	if err := m.auditLogger.Record(action, profile, m.claudeManager.SettingsPath(), apiKey); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// AuditEntries returns the retained audit log entries, oldest first
+func (m *Manager) AuditEntries() ([]audit.Entry, error) {
+	return m.auditLogger.Entries()
 }
 
 // SetAutoStart sets the auto-start state and updates system auto-start configuration
@@ -275,5 +605,15 @@ func (m *Manager) SetAutoStart(enabled bool) error {
 		}
 	}
 
-	return m.Save()
+	return m.saveAndPublish(events.AutostartChanged{Enabled: enabled})
+}
+
+// SetClipboardClearSeconds sets how long copied secrets stay on the clipboard
+func (m *Manager) SetClipboardClearSeconds(seconds int) error {
+	if seconds <= 0 {
+		return fmt.Errorf("clipboard clear delay must be positive, got %d", seconds)
+	}
+
+	m.settings.ClipboardClearSeconds = seconds
+	return m.saveAndPublish(events.SettingsChanged{})
 }
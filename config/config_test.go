@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestSwitchProfileRunsHooksOutsideWith(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh")
+	}
+	m, home := newTestManager(t)
+	marker := filepath.Join(home, "hook-ran")
+
+	profile := models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: "sk-switch-test-0123456789"}
+	profile.Hooks.PreSwitch = []models.Hook{{Command: "touch " + marker}}
+	if err := m.AddProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+
+	// Record whether the hook had run each time the manager was taken
+	var ran []bool
+	inWith := false
+	with := func(fn func(m *Manager) error) error {
+		inWith = true
+		defer func() { inWith = false }()
+		_, err := os.Stat(marker)
+		ran = append(ran, err == nil)
+		return fn(m)
+	}
+	if err := SwitchProfile(with, "work"); err != nil {
+		t.Fatalf("SwitchProfile() error = %v", err)
+	}
+	if inWith {
+		t.Error("with was left held")
+	}
+	if len(ran) != 2 || ran[0] || !ran[1] {
+		t.Errorf("hook ran before with calls = %v, want [false true]", ran)
+	}
+	if active := m.GetSettings().GetActiveProfile(); active == nil || active.Name != "work" {
+		t.Errorf("active profile = %v, want work", active)
+	}
+}
+
+func TestSwitchProfileErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh")
+	}
+	m, _ := newTestManager(t)
+	with := func(fn func(m *Manager) error) error { return fn(m) }
+
+	abort := models.Profile{Name: "abort", APIURL: "https://a.example.com", APIKey: "sk-abort-test-0123456789"}
+	abort.Hooks.PreSwitch = []models.Hook{{Command: "exit 1"}}
+	post := models.Profile{Name: "post", APIURL: "https://p.example.com", APIKey: "sk-post-test-0123456789"}
+	post.Hooks.PostSwitch = []models.Hook{{Command: "exit 2"}}
+	for _, p := range []models.Profile{abort, post} {
+		if err := m.AddProfile(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		want   error
+		active string
+	}{
+		{"missing", ErrProfileNotFound, ""},
+		{"abort", ErrSwitchAborted, ""},
+		{"post", ErrPostSwitchHook, "post"},
+	}
+	for _, tt := range tests {
+		if err := SwitchProfile(with, tt.name); !errors.Is(err, tt.want) {
+			t.Errorf("SwitchProfile(%s) error = %v, want %v", tt.name, err, tt.want)
+		}
+		active := ""
+		if p := m.GetSettings().GetActiveProfile(); p != nil {
+			active = p.Name
+		}
+		if active != tt.active {
+			t.Errorf("after %s: active = %q, want %q", tt.name, active, tt.active)
+		}
+	}
+}
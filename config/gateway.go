@@ -0,0 +1,72 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ipfans/cc-quick-profile/audit"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// gatewayTokenPrefix marks gateway tokens so they are not mistaken for
+// provider keys
+const gatewayTokenPrefix = "ccqp-gw-"
+
+// SetGatewayEnabled turns the local gateway on or off. Turning it on creates
+// the gateway token on first use. While the app is enabled, Claude Code is
+// pointed at the gateway or back at the active profile right away. If that
+// or saving fails, the gateway settings and Claude Code are left as they were.
+func (m *Manager) SetGatewayEnabled(enabled bool) error {
+	previous := m.settings.Gateway
+	gateway := previous
+	if enabled {
+		if err := gateway.Validate(); err != nil {
+			return err
+		}
+		if gateway.Token == "" {
+			token, err := newGatewayToken()
+			if err != nil {
+				return err
+			}
+			gateway.Token = token
+			redact.Register(token)
+		}
+	}
+	gateway.Enabled = enabled
+
+	// The credentials written to Claude Code are derived from the settings,
+	// so the copy is put in place and taken back out on failure
+	m.settings.Gateway = gateway
+	active := m.settings.GetActiveProfile()
+	written := m.settings.Enabled && active != nil
+	if written {
+		if err := m.writeClaudeCredentials(audit.ActionGateway, *active); err != nil {
+			m.settings.Gateway = previous
+			return err
+		}
+	}
+
+	if err := m.saveAndPublish(events.SettingsChanged{}); err != nil {
+		m.settings.Gateway = previous
+		if written {
+			apiKey, apiURL := m.settings.ClaudeCredentials(*active)
+			if restoreErr := m.claudeManager.SetAuthConfig(apiKey, apiURL); restoreErr != nil {
+				return fmt.Errorf("%w (and failed to restore Claude auth config: %v)", err, restoreErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// newGatewayToken returns a random token for Claude Code to authenticate to
+// the gateway with
+func newGatewayToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate gateway token: %w", err)
+	}
+	return gatewayTokenPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestSetGatewayEnabledRollsBack(t *testing.T) {
+	m, _ := newTestManager(t)
+	profile := models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: "sk-gateway-test-0123456789"}
+	if err := m.AddProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetActiveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the config file makes saving fail
+	if err := os.Remove(m.ConfigPath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(m.ConfigPath(), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SetGatewayEnabled(true); err == nil {
+		t.Fatal("SetGatewayEnabled() error = nil, want the save failure")
+	}
+	if gateway := m.GetSettings().Gateway; gateway.Enabled || gateway.Token != "" {
+		t.Errorf("gateway = %+v, want it left disabled without a token", gateway)
+	}
+
+	apiKey, apiURL, err := claude.ReadAuthConfig(m.ClaudeSettingsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiKey != profile.APIKey || apiURL != profile.APIURL {
+		t.Errorf("Claude credentials = %s, %s, want the profile's", apiKey, apiURL)
+	}
+}
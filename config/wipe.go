@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/audit"
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+)
+
+// WipeResult describes what an emergency wipe did to a single location
+type WipeResult struct {
+	Path    string // Location that was processed
+	Cleaned bool   // Whether credentials were found and removed
+	Detail  string // Human-readable description of the outcome
+	Err     error  // Error encountered, if any
+}
+
+// WipeCredentials removes every credential the application has deployed and
+// erases the profile store. It visits the Claude settings file, every target
+// recorded in the audit log and every discovered file holding a stored
+// secret, continuing past individual failures so that as much as possible
+// is cleaned, and returns one result per location.
+func (m *Manager) WipeCredentials() []WipeResult {
+	return WipeCredentials(func(fn func(m *Manager) error) error {
+		return fn(m)
+	})
+}
+
+// WipeCredentials wipes like Manager.WipeCredentials, but only holds the
+// manager while taking a copy of the stored secrets and while erasing the
+// profile store. with must run fn with exclusive access to the manager;
+// targets are discovered and rewritten on the calling goroutine in between.
+func WipeCredentials(with func(fn func(m *Manager) error) error) []WipeResult {
+	var w *credentialWipe
+	if err := with(func(m *Manager) error {
+		w = m.prepareWipe()
+		return nil
+	}); err != nil {
+		return []WipeResult{{Err: err}}
+	}
+
+	results := w.wipeTargets()
+
+	store := WipeResult{Path: w.configPath}
+	if err := with(func(m *Manager) error {
+		store = m.wipeProfileStore()
+		return nil
+	}); err != nil {
+		store.Err = err
+	}
+	return append(results, store)
+}
+
+// credentialWipe holds copies of what cleaning the written files needs, so
+// it can run without access to the manager
+type credentialWipe struct {
+	settingsPath string
+	configPath   string
+	secrets      []string // Profile keys and the gateway token
+	auditLogger  *audit.Logger
+}
+
+// prepareWipe collects the stored secrets and the paths a wipe visits
+func (m *Manager) prepareWipe() *credentialWipe {
+	w := &credentialWipe{
+		settingsPath: m.claudeManager.SettingsPath(),
+		configPath:   m.configPath,
+		auditLogger:  m.auditLogger,
+	}
+	for _, p := range m.settings.Profiles {
+		if p.APIKey != "" {
+			w.secrets = append(w.secrets, p.APIKey)
+		}
+	}
+	if m.settings.Gateway.Token != "" {
+		w.secrets = append(w.secrets, m.settings.Gateway.Token)
+	}
+	return w
+}
+
+// wipeTargets cleans the Claude settings file, the recorded targets and the
+// discovered files, returning one result per location
+func (w *credentialWipe) wipeTargets() []WipeResult {
+	results := []WipeResult{}
+	targets := []string{w.settingsPath}
+	addTarget := func(path string) {
+		if !slices.Contains(targets, path) {
+			targets = append(targets, path)
+		}
+	}
+
+	recorded, err := w.auditLogger.Targets()
+	if err != nil {
+		results = append(results, WipeResult{
+			Path: w.auditLogger.Path(),
+			Err:  fmt.Errorf("failed to read written targets: %w", err),
+		})
+	}
+	for _, target := range recorded {
+		addTarget(target)
+	}
+
+	discovered, err := w.discoverTargets()
+	if err != nil {
+		results = append(results, WipeResult{
+			Path: filepath.Dir(w.settingsPath),
+			Err:  err,
+		})
+	}
+	for _, target := range discovered {
+		addTarget(target)
+	}
+
+	for _, target := range targets {
+		results = append(results, w.wipeTarget(target))
+	}
+	return results
+}
+
+// discoverTargets finds files the audit log does not know about that hold a
+// stored key or the gateway token: Claude Code settings of the user and of
+// known projects, env files next to those projects, and shell startup files
+// where exported variables may have been pasted
+func (w *credentialWipe) discoverTargets() ([]string, error) {
+	if len(w.secrets) == 0 {
+		return nil, nil
+	}
+
+	var candidates []string
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".claude", "settings.local.json"),
+			filepath.Join(home, ".profile"),
+			filepath.Join(home, ".bashrc"),
+			filepath.Join(home, ".bash_profile"),
+			filepath.Join(home, ".zshrc"),
+			filepath.Join(home, ".zshenv"),
+			filepath.Join(home, ".config", "fish", "config.fish"),
+		)
+	}
+
+	projects, err := claude.RecentProjects(0)
+	if err != nil {
+		err = fmt.Errorf("failed to list Claude Code projects: %w", err)
+	}
+	for _, project := range projects {
+		candidates = append(candidates,
+			filepath.Join(project.Dir, ".claude", "settings.json"),
+			filepath.Join(project.Dir, ".claude", "settings.local.json"),
+			filepath.Join(project.Dir, ".env"),
+			filepath.Join(project.Dir, ".envrc"),
+		)
+	}
+
+	var found []string
+	for _, path := range candidates {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			continue
+		}
+		if w.holdsSecret(data) {
+			found = append(found, path)
+		}
+	}
+	return found, err
+}
+
+// holdsSecret reports whether data contains a stored key or the gateway token
+func (w *credentialWipe) holdsSecret(data []byte) bool {
+	return slices.ContainsFunc(w.secrets, func(secret string) bool {
+		return strings.Contains(string(data), secret)
+	})
+}
+
+// wipeTarget removes managed credentials from a single written file. A file
+// that still holds a stored secret afterwards is reported as a failure.
+func (w *credentialWipe) wipeTarget(path string) WipeResult {
+	result := WipeResult{Path: path}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			result.Detail = "文件不存在，已跳过"
+			return result
+		}
+		result.Err = fmt.Errorf("failed to check file: %w", err)
+		return result
+	}
+
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		result.Cleaned, err = claude.RemoveAuthConfigFile(path)
+	} else {
+		result.Cleaned, err = shellenv.StripFile(path, w.secrets)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if data, err := os.ReadFile(path); err != nil {
+		result.Err = fmt.Errorf("failed to check file: %w", err)
+		return result
+	} else if w.holdsSecret(data) {
+		result.Err = errors.New("stored credentials remain in the file, remove them by hand")
+		return result
+	}
+
+	if !result.Cleaned {
+		result.Detail = "未发现凭据"
+		return result
+	}
+
+	result.Detail = "已移除凭据"
+	if err := w.auditLogger.Record(audit.ActionWipe, "", path, ""); err != nil {
+		result.Err = fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return result
+}
+
+// wipeProfileStore erases all stored profiles, webhook signing secrets and
+// the gateway token, and disables the application and the gateway
+func (m *Manager) wipeProfileStore() WipeResult {
+	result := WipeResult{Path: m.configPath}
+	count := len(m.settings.Profiles)
+
+	m.settings.Profiles = []models.Profile{}
+	m.settings.Enabled = false
+	for i := range m.settings.Webhooks {
+		m.settings.Webhooks[i].Secret = ""
+	}
+	m.settings.Gateway.Enabled = false
+	m.settings.Gateway.Token = ""
+	if err := m.saveAndPublish(events.SettingsChanged{}); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Cleaned = count > 0
+	result.Detail = fmt.Sprintf("已删除 %d 个配置", count)
+	return result
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// newTestManager creates a manager whose config, Claude settings and shell
+// files all live in a temporary home directory
+func newTestManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", filepath.Join(home, "AppData"))
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return m, home
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWipeCredentials(t *testing.T) {
+	m, home := newTestManager(t)
+	const key = "sk-wipe-test-0123456789"
+
+	if err := m.AddProfile(models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetActiveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A project in Claude Code's history with the key in its .env, and shell
+	// files with the key in an export and in an alias
+	project := filepath.Join(home, "src", "app")
+	writeFile(t, filepath.Join(home, ".claude", "projects", "app", "s.jsonl"), `{"cwd":"`+filepath.ToSlash(project)+`"}`+"\n")
+	writeFile(t, filepath.Join(project, ".env"), "DEBUG=1\nANTHROPIC_AUTH_TOKEN="+key+"\n")
+	writeFile(t, filepath.Join(home, ".zshrc"), "export PATH=/bin\nexport ANTHROPIC_AUTH_TOKEN='"+key+"'\n")
+	writeFile(t, filepath.Join(home, ".bashrc"), "export ANTHROPIC_AUTH_TOKEN="+key+"\nalias old='claude --key "+key+"'\n")
+	writeFile(t, filepath.Join(home, ".profile"), "export PATH=/bin\n")
+
+	results := m.WipeCredentials()
+	byPath := make(map[string]WipeResult)
+	for _, r := range results {
+		byPath[r.Path] = r
+	}
+
+	tests := []struct {
+		path    string
+		cleaned bool
+		failed  bool
+		want    string // Expected file content, if checked
+	}{
+		{m.ClaudeSettingsPath(), true, false, ""},
+		{filepath.Join(project, ".env"), true, false, "DEBUG=1\n"},
+		{filepath.Join(home, ".zshrc"), true, false, "export PATH=/bin\n"},
+		{filepath.Join(home, ".bashrc"), true, true, "alias old='claude --key " + key + "'\n"},
+		{m.ConfigPath(), true, false, ""},
+	}
+	for _, tt := range tests {
+		r, ok := byPath[tt.path]
+		if !ok {
+			t.Errorf("no result for %s", tt.path)
+			continue
+		}
+		if r.Cleaned != tt.cleaned {
+			t.Errorf("%s: Cleaned = %v, want %v", tt.path, r.Cleaned, tt.cleaned)
+		}
+		if (r.Err != nil) != tt.failed {
+			t.Errorf("%s: Err = %v, want failure %v", tt.path, r.Err, tt.failed)
+		}
+		if tt.want == "" {
+			continue
+		}
+		data, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, data, tt.want)
+		}
+	}
+
+	if _, ok := byPath[filepath.Join(home, ".profile")]; ok {
+		t.Error("a shell file without credentials was visited")
+	}
+	if data, err := os.ReadFile(m.ClaudeSettingsPath()); err != nil || strings.Contains(string(data), key) {
+		t.Errorf("Claude settings still hold the key: %s, %v", data, err)
+	}
+
+	settings, err := ReadSettings(m.ConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings.Profiles) != 0 || settings.Enabled {
+		t.Errorf("profile store = %+v, want no profiles and disabled", settings)
+	}
+}
+
+func TestWipeCredentialsWithoutSecrets(t *testing.T) {
+	m, home := newTestManager(t)
+	writeFile(t, filepath.Join(home, ".bashrc"), "export ANTHROPIC_AUTH_TOKEN=sk-unknown-0123456789\n")
+
+	for _, r := range m.WipeCredentials() {
+		if r.Err != nil {
+			t.Errorf("%s: Err = %v", r.Path, r.Err)
+		}
+		if r.Path == filepath.Join(home, ".bashrc") {
+			t.Error("a shell file without stored secrets was visited")
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotRunning is returned by Dial when no process serves the socket
+var ErrNotRunning = errors.New("no running instance")
+
+// ErrTimeout is returned by Call when the running app does not answer in time
+var ErrTimeout = errors.New("running instance did not respond")
+
+// dialTimeout bounds connecting to the socket
+const dialTimeout = time.Second
+
+var (
+	// callTimeout bounds a call, from sending the request to reading the response
+	callTimeout = 10 * time.Second
+	// slowCallTimeout bounds calls that run switch hooks or search for files
+	slowCallTimeout = 2 * time.Minute
+)
+
+// slowMethods are the methods given slowCallTimeout
+var slowMethods = map[string]bool{
+	MethodActivate: true,
+	MethodWipe:     true,
+}
+
+// Client calls the control API
+type Client struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	nextID  int
+	pending []Event // Notifications received while waiting for a response
+}
+
+// Dial connects to the running app, returning ErrNotRunning if there is none
+func Dial() (*Client, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+
+	return &Client{conn: conn, reader: bufio.NewReaderSize(conn, 4096)}, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call invokes method and decodes its result into result, which may be nil.
+// Errors reported by the server are returned as *Error, and ErrTimeout if
+// the server does not respond in time.
+func (c *Client) Call(method string, params, result interface{}) error {
+	timeout := callTimeout
+	if slowMethods[method] {
+		timeout = slowCallTimeout
+	}
+	if err := c.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+	defer c.conn.SetDeadline(time.Time{})
+
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+
+	req := message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		req.Params = data
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("%w within %s", ErrTimeout, timeout)
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	for {
+		msg, err := c.read()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("%w within %s", ErrTimeout, timeout)
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == notificationMethod {
+			if event, ok := decodeEvent(msg); ok {
+				c.pending = append(c.pending, event)
+			}
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			continue
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("failed to decode result: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// Next blocks until the next event after a successful subscribe
+func (c *Client) Next() (Event, error) {
+	if len(c.pending) > 0 {
+		event := c.pending[0]
+		c.pending = c.pending[1:]
+		return event, nil
+	}
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return Event{}, err
+		}
+		if event, ok := decodeEvent(msg); ok && msg.Method == notificationMethod {
+			return event, nil
+		}
+	}
+}
+
+// read returns the next message from the server
+func (c *Client) read() (message, error) {
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return message{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return message{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return msg, nil
+}
+
+// decodeEvent extracts the event from a notification
+func decodeEvent(msg message) (Event, bool) {
+	var event Event
+	if err := json.Unmarshal(msg.Params, &event); err != nil {
+		return Event{}, false
+	}
+	return event, true
+}
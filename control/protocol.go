@@ -0,0 +1,287 @@
+// Package control implements a JSON-RPC 2.0 API on a user-only Unix socket,
+// letting scripts and the CLI drive the running app
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/instance"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// Methods exposed by the server
+const (
+	MethodList      = "list"
+	MethodActivate  = "activate"
+	MethodAdd       = "add"
+	MethodEdit      = "edit"
+	MethodRemove    = "remove"
+	MethodEnable    = "enable"
+	MethodDisable   = "disable"
+	MethodGateway   = "gateway"
+	MethodWipe      = "wipe"
+	MethodStatus    = "status"
+	MethodSubscribe = "subscribe"
+)
+
+// notificationMethod is the method of event notifications sent to subscribers
+const notificationMethod = "event"
+
+// Error codes. The negative values below -32000 are reserved by JSON-RPC.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	CodeProfileNotFound = 1 // The named profile does not exist
+	CodeProfileExists   = 2 // A profile with the name already exists
+	CodeSwitchAborted   = 3 // A pre-switch hook failed
+)
+
+// ActivateParams are the parameters of activate
+type ActivateParams struct {
+	Name string `json:"name"`
+}
+
+// AddParams are the parameters of add
+type AddParams struct {
+	Name   string `json:"name"`
+	APIURL string `json:"apiUrl"`
+	APIKey string `json:"apiKey"`
+	Color  string `json:"color,omitempty"`
+}
+
+// EditParams are the parameters of edit. Omitted fields keep their value.
+type EditParams struct {
+	Name          string  `json:"name"`
+	NewName       *string `json:"newName,omitempty"`
+	APIURL        *string `json:"apiUrl,omitempty"`
+	APIKey        *string `json:"apiKey,omitempty"`
+	Color         *string `json:"color,omitempty"`
+	ConfirmSwitch *bool   `json:"confirmSwitch,omitempty"`
+}
+
+// apply returns p with the given fields changed
+func (params EditParams) apply(p models.Profile) models.Profile {
+	if params.NewName != nil {
+		p.Name = strings.TrimSpace(*params.NewName)
+	}
+	if params.APIURL != nil {
+		p.APIURL = strings.TrimSpace(*params.APIURL)
+	}
+	if params.APIKey != nil {
+		p.APIKey = strings.TrimSpace(*params.APIKey)
+	}
+	if params.Color != nil {
+		p.Color = *params.Color
+	}
+	if params.ConfirmSwitch != nil {
+		p.ConfirmSwitch = *params.ConfirmSwitch
+	}
+	return p
+}
+
+// RemoveParams are the parameters of remove
+type RemoveParams struct {
+	Name string `json:"name"`
+}
+
+// GatewayParams are the parameters of gateway. Without enabled the state is
+// only reported.
+type GatewayParams struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// Gateway is the result of gateway
+type Gateway struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url,omitempty"` // Set while enabled
+}
+
+// WipeResult is one entry in the result of wipe
+type WipeResult struct {
+	Path   string `json:"path"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Profile describes a profile without its secret
+type Profile struct {
+	Name   string `json:"name"`
+	APIURL string `json:"apiUrl"`
+	APIKey string `json:"apiKey"` // Masked
+	Active bool   `json:"active"`
+	Color  string `json:"color"`
+}
+
+// Status is the result of status, activate, edit, enable and disable
+type Status struct {
+	Enabled       bool          `json:"enabled"`
+	AutoStart     bool          `json:"autoStart"`
+	ActiveProfile string        `json:"activeProfile"` // Empty if none is active
+	ProfileCount  int           `json:"profileCount"`
+	Claude        *ClaudeStatus `json:"claude,omitempty"`  // Set by status
+	Warning       string        `json:"warning,omitempty"` // Set by activate when a post-switch hook failed
+}
+
+// ClaudeStatus describes the credentials in Claude Code's settings file
+type ClaudeStatus struct {
+	SettingsPath string `json:"settingsPath"`
+	Configured   bool   `json:"configured"`
+	InSync       bool   `json:"inSync"`
+	BaseURL      string `json:"baseUrl,omitempty"` // Masked
+}
+
+// Event is the payload of a notification sent to subscribers
+type Event struct {
+	Type    string `json:"type"`              // The Kind of the bus event
+	Profile string `json:"profile,omitempty"` // The profile concerned, if any
+	OldName string `json:"oldName,omitempty"` // The previous name of a renamed profile
+	Enabled *bool  `json:"enabled,omitempty"` // The new state of a toggle
+}
+
+// newEvent converts a bus event to its wire form
+func newEvent(e events.Event) Event {
+	event := Event{Type: e.Kind()}
+	switch e := e.(type) {
+	case events.ProfileActivated:
+		event.Profile = e.Name
+	case events.ProfileAdded:
+		event.Profile = e.Name
+	case events.ProfileDeleted:
+		event.Profile = e.Name
+	case events.ProfileUpdated:
+		event.Profile = e.Name
+		if e.OldName != e.Name {
+			event.OldName = e.OldName
+		}
+	case events.EnabledChanged:
+		event.Enabled = &e.Enabled
+	case events.AutostartChanged:
+		event.Enabled = &e.Enabled
+	case events.ClaudeSettingsDrifted:
+		event.Profile = e.Profile
+	}
+	return event
+}
+
+// Error is a JSON-RPC error returned by the server
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the server's message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match the config sentinel errors the code stands for
+func (e *Error) Is(target error) bool {
+	switch e.Code {
+	case CodeProfileNotFound:
+		return target == config.ErrProfileNotFound
+	case CodeProfileExists:
+		return target == config.ErrProfileExists
+	case CodeSwitchAborted:
+		return target == config.ErrSwitchAborted
+	}
+	return false
+}
+
+// message is any JSON-RPC 2.0 request, response or notification
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// SocketPath returns the path of the control socket
+func SocketPath() (string, error) {
+	dir, err := instance.RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "control.sock"), nil
+}
+
+// newProfile converts a profile, masking its key
+func newProfile(p models.Profile) Profile {
+	return Profile{
+		Name:   p.Name,
+		APIURL: redact.String(p.APIURL),
+		APIKey: redact.MaskKey(p.APIKey),
+		Active: p.Active,
+		Color:  p.DisplayColor(),
+	}
+}
+
+// newStatus summarises the settings
+func newStatus(settings *models.Settings) Status {
+	status := Status{
+		Enabled:      settings.Enabled,
+		AutoStart:    settings.AutoStart,
+		ProfileCount: len(settings.Profiles),
+	}
+	if active := settings.GetActiveProfile(); active != nil {
+		status.ActiveProfile = active.Name
+	}
+	return status
+}
+
+// newClaudeStatus reads the credentials in Claude Code's settings file
+func newClaudeStatus(m *config.Manager) (*ClaudeStatus, error) {
+	apiKey, apiURL, err := m.ClaudeAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	inSync, err := m.ClaudeInSync()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClaudeStatus{
+		SettingsPath: m.ClaudeSettingsPath(),
+		Configured:   apiKey != "" && apiURL != "",
+		InSync:       inSync,
+		BaseURL:      redact.String(apiURL),
+	}, nil
+}
+
+// NewWipeResults converts the results of a wipe, removing secrets from errors
+func NewWipeResults(results []config.WipeResult) []WipeResult {
+	converted := make([]WipeResult, 0, len(results))
+	for _, r := range results {
+		result := WipeResult{Path: r.Path, Detail: r.Detail}
+		if r.Err != nil {
+			result.Error = redact.Error(r.Err).Error()
+		}
+		converted = append(converted, result)
+	}
+	return converted
+}
+
+// toError converts a manager error to a JSON-RPC error with secrets removed
+func toError(err error) *Error {
+	code := CodeInternalError
+	switch {
+	case errors.Is(err, config.ErrProfileNotFound):
+		code = CodeProfileNotFound
+	case errors.Is(err, config.ErrProfileExists):
+		code = CodeProfileExists
+	case errors.Is(err, config.ErrSwitchAborted):
+		code = CodeSwitchAborted
+	}
+	return &Error{Code: code, Message: redact.Error(err).Error()}
+}
@@ -0,0 +1,189 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/events"
+)
+
+// pipe serves a server without a manager on one end of an in-memory
+// connection and returns the other end
+func pipe(t *testing.T) (*Server, net.Conn) {
+	s := &Server{
+		with:        func(fn func(m *config.Manager) error) error { return nil },
+		subscribers: map[*conn]bool{},
+	}
+	server, client := net.Pipe()
+	go s.handle(&conn{Conn: server})
+	t.Cleanup(func() { client.Close() })
+	return s, client
+}
+
+func TestFraming(t *testing.T) {
+	_, c := pipe(t)
+	reader := bufio.NewReader(c)
+
+	tests := []struct {
+		name    string
+		request string
+		want    string // The expected response line; empty if none is sent
+	}{
+		{"parse error", `{"jsonrpc":`, `"id":null,"error":{"code":-32700`},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"list"}`, `"id":1,"error":{"code":-32600`},
+		{"no method", `{"jsonrpc":"2.0","id":"a"}`, `"id":"a","error":{"code":-32600`},
+		{"unknown method", `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`, `"id":2,"error":{"code":-32601`},
+		{"missing params", `{"jsonrpc":"2.0","id":3,"method":"activate"}`, `"id":3,"error":{"code":-32602`},
+		{"notification", `{"jsonrpc":"2.0","method":"list"}`, ""},
+		{"result", `{"jsonrpc":"2.0","id":4,"method":"list"}`, `"id":4,"result":[]`},
+	}
+	for _, tt := range tests {
+		if _, err := fmt.Fprintf(c, "%s\n", tt.request); err != nil {
+			t.Fatalf("%s: write error = %v", tt.name, err)
+		}
+		if tt.want == "" {
+			continue // The next response must belong to the next request
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("%s: read error = %v", tt.name, err)
+		}
+		if !strings.HasPrefix(line, `{"jsonrpc":"2.0",`) || !strings.Contains(line, tt.want) {
+			t.Errorf("%s: response = %s, want it to contain %s", tt.name, line, tt.want)
+		}
+	}
+}
+
+func TestOversizedRequest(t *testing.T) {
+	_, c := pipe(t)
+	go fmt.Fprintf(c, "%s\n", strings.Repeat("x", maxMessageSize+1))
+
+	// The server gives up on the connection instead of buffering without bound
+	if _, err := io.ReadAll(c); err != nil {
+		t.Errorf("read error = %v, want the connection closed", err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	s, c := pipe(t)
+	client := &Client{conn: c, reader: bufio.NewReader(c)}
+
+	var profiles []Profile
+	if err := client.Call(MethodList, nil, &profiles); err != nil || profiles == nil {
+		t.Fatalf("Call(list) = %v, %v, want an empty list", profiles, err)
+	}
+
+	var rpcErr *Error
+	err := client.Call(MethodActivate, ActivateParams{}, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("Call(activate) error = %v, want code %d", err, CodeInvalidParams)
+	}
+
+	if err := client.Call(MethodSubscribe, nil, nil); err != nil {
+		t.Fatalf("Call(subscribe) error = %v", err)
+	}
+	go s.publish(newEvent(events.ProfileUpdated{OldName: "old", Name: "new"}))
+	event, err := client.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if event.Type != "profileUpdated" || event.Profile != "new" || event.OldName != "old" {
+		t.Errorf("Next() = %+v, want the rename", event)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { callTimeout = timeout }(callTimeout)
+	callTimeout = 50 * time.Millisecond
+
+	// A server whose manager is never free, like a tray with a busy UI thread
+	release := make(chan struct{})
+	defer close(release)
+	s := &Server{
+		with: func(fn func(m *config.Manager) error) error {
+			<-release
+			return nil
+		},
+		subscribers: map[*conn]bool{},
+	}
+	server, c := net.Pipe()
+	go s.handle(&conn{Conn: server})
+	defer c.Close()
+
+	client := &Client{conn: c, reader: bufio.NewReader(c)}
+	done := make(chan error, 1)
+	go func() { done <- client.Call(MethodStatus, nil, nil) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Call(status) error = %v, want ErrTimeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Call(status) did not time out")
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	encode := func(e Event) string {
+		data, _ := json.Marshal(e)
+		return string(data)
+	}
+	tests := []struct {
+		event events.Event
+		want  string
+	}{
+		{events.ProfileActivated{Name: "a"}, `{"type":"profileActivated","profile":"a"}`},
+		{events.ProfileUpdated{OldName: "a", Name: "a"}, `{"type":"profileUpdated","profile":"a"}`},
+		{events.EnabledChanged{Enabled: false}, `{"type":"enabledChanged","enabled":false}`},
+		{events.SettingsChanged{}, `{"type":"settingsChanged"}`},
+	}
+	for _, tt := range tests {
+		if got := encode(newEvent(tt.event)); got != tt.want {
+			t.Errorf("newEvent(%#v) = %s, want %s", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("failed: %w", config.ErrProfileNotFound), CodeProfileNotFound},
+		{fmt.Errorf("failed: %w", config.ErrProfileExists), CodeProfileExists},
+		{fmt.Errorf("failed: %w", config.ErrSwitchAborted), CodeSwitchAborted},
+		{errors.New("disk full"), CodeInternalError},
+	}
+	for _, tt := range tests {
+		rpcErr := toError(tt.err)
+		if rpcErr.Code != tt.code {
+			t.Errorf("toError(%v).Code = %d, want %d", tt.err, rpcErr.Code, tt.code)
+		}
+		// The code survives the round trip and still matches the sentinel
+		var decoded *Error
+		data, _ := json.Marshal(rpcErr)
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		for _, sentinel := range []error{config.ErrProfileNotFound, config.ErrProfileExists, config.ErrSwitchAborted} {
+			if errors.Is(decoded, sentinel) != errors.Is(tt.err, sentinel) {
+				t.Errorf("errors.Is(%v, %v) = %v after decoding", tt.err, sentinel, !errors.Is(tt.err, sentinel))
+			}
+		}
+	}
+}
+
+func TestToErrorRedacts(t *testing.T) {
+	err := toError(errors.New("upstream rejected sk-ant-REDACTED"))
+	if strings.Contains(err.Message, "abcdefghijklmnop") {
+		t.Errorf("toError() message = %q, want the key masked", err.Message)
+	}
+}
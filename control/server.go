@@ -0,0 +1,359 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// ErrInUse is returned by Listen when another process serves the socket
+var ErrInUse = errors.New("control socket is in use")
+
+// maxMessageSize bounds a single request line
+const maxMessageSize = 1 << 20
+
+// writeTimeout stops a stalled subscriber from blocking the publisher
+const writeTimeout = 5 * time.Second
+
+// Server answers JSON-RPC requests on the control socket
+type Server struct {
+	listener    net.Listener
+	with        func(fn func(m *config.Manager) error) error
+	unsubscribe func()
+
+	mu          sync.Mutex
+	subscribers map[*conn]bool
+}
+
+// conn is a client connection; writes are serialised so notifications and
+// responses do not interleave
+type conn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+// Listen serves the control socket. with must run fn with exclusive access
+// to the config manager. Events published on bus are forwarded to subscribers.
+func Listen(bus *events.Bus, with func(fn func(m *config.Manager) error) error) (*Server, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only remove a socket nobody accepts on
+	if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		c.Close()
+		return nil, ErrInUse
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to secure control socket: %w", err)
+	}
+
+	s := &Server{
+		listener:    listener,
+		with:        with,
+		subscribers: map[*conn]bool{},
+	}
+	s.unsubscribe = bus.Subscribe(func(e events.Event) {
+		s.publish(newEvent(e))
+	})
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server and removes the socket
+func (s *Server) Close() error {
+	s.unsubscribe()
+	return s.listener.Close()
+}
+
+// publish sends an event to every subscriber
+func (s *Server) publish(event Event) {
+	params, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	notification := message{JSONRPC: "2.0", Method: notificationMethod, Params: params}
+
+	s.mu.Lock()
+	subscribers := make([]*conn, 0, len(s.subscribers))
+	for c := range s.subscribers {
+		subscribers = append(subscribers, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range subscribers {
+		if err := c.write(notification); err != nil {
+			c.Close()
+		}
+	}
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(&conn{Conn: c})
+	}
+}
+
+// handle answers requests on a connection until it is closed
+func (s *Server) handle(c *conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	scanner := bufio.NewScanner(c)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	for scanner.Scan() {
+		var req message
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			c.write(message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			c.write(message{JSONRPC: "2.0", ID: req.ID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+
+		result, rpcErr := s.dispatch(c, req)
+		if req.ID == nil {
+			continue // Notifications get no response
+		}
+
+		resp := message{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+		if rpcErr == nil {
+			data, err := json.Marshal(result)
+			if err != nil {
+				resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+			} else {
+				resp.Result = data
+			}
+		}
+		if err := c.write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch runs a request and returns its result
+func (s *Server) dispatch(c *conn, req message) (interface{}, *Error) {
+	switch req.Method {
+	case MethodList:
+		profiles := []Profile{}
+		err := s.with(func(m *config.Manager) error {
+			for _, p := range m.GetSettings().Profiles {
+				profiles = append(profiles, newProfile(p))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return profiles, nil
+
+	case MethodStatus:
+		var status Status
+		err := s.with(func(m *config.Manager) error {
+			status = newStatus(m.GetSettings())
+			claude, err := newClaudeStatus(m)
+			status.Claude = claude
+			return err
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return status, nil
+
+	case MethodActivate:
+		var params ActivateParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "name is required"}
+		}
+
+		// Hooks run on this connection's goroutine, outside with
+		switchErr := config.SwitchProfile(s.with, params.Name)
+		if switchErr != nil && !errors.Is(switchErr, config.ErrPostSwitchHook) {
+			return nil, toError(switchErr)
+		}
+
+		var status Status
+		err := s.with(func(m *config.Manager) error {
+			status = newStatus(m.GetSettings())
+			return nil
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		if switchErr != nil {
+			status.Warning = redact.Error(switchErr).Error()
+		}
+		return status, nil
+
+	case MethodAdd:
+		var params AddParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		profile := models.Profile{Name: params.Name, APIURL: params.APIURL, APIKey: params.APIKey, Color: params.Color}
+		if err := profile.Validate(); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+
+		err := s.with(func(m *config.Manager) error {
+			return m.AddProfile(profile)
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return true, nil
+
+	case MethodEdit:
+		var params EditParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "name is required"}
+		}
+
+		var status Status
+		var invalid error
+		err := s.with(func(m *config.Manager) error {
+			existing := m.GetSettings().FindProfile(params.Name)
+			if existing == nil {
+				return fmt.Errorf("%w: '%s'", config.ErrProfileNotFound, params.Name)
+			}
+			updated := params.apply(*existing)
+			if invalid = updated.Validate(); invalid != nil {
+				return nil
+			}
+			if updated.Name != params.Name && m.GetSettings().FindProfile(updated.Name) != nil {
+				return fmt.Errorf("%w: '%s'", config.ErrProfileExists, updated.Name)
+			}
+
+			if err := m.UpdateProfile(params.Name, updated); err != nil {
+				return err
+			}
+			// Rewrite the active profile so Claude Code picks up the new values
+			if updated.Active {
+				if err := m.RefreshCredentials(); err != nil {
+					return err
+				}
+			}
+			status = newStatus(m.GetSettings())
+			return nil
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		if invalid != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: invalid.Error()}
+		}
+		return status, nil
+
+	case MethodRemove:
+		var params RemoveParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "name is required"}
+		}
+
+		err := s.with(func(m *config.Manager) error {
+			return m.DeleteProfile(params.Name)
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return true, nil
+
+	case MethodEnable, MethodDisable:
+		var status Status
+		err := s.with(func(m *config.Manager) error {
+			if err := m.SetEnabled(req.Method == MethodEnable); err != nil {
+				return err
+			}
+			status = newStatus(m.GetSettings())
+			return nil
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return status, nil
+
+	case MethodGateway:
+		var params GatewayParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+			}
+		}
+
+		var gateway Gateway
+		err := s.with(func(m *config.Manager) error {
+			if params.Enabled != nil {
+				if err := m.SetGatewayEnabled(*params.Enabled); err != nil {
+					return err
+				}
+			}
+			settings := m.GetSettings().Gateway
+			gateway.Enabled = settings.Enabled
+			if settings.Enabled {
+				gateway.URL = settings.URL()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return gateway, nil
+
+	case MethodWipe:
+		// Files are cleaned without holding the manager, which is only
+		// taken to copy the secrets and to erase the profile store
+		return NewWipeResults(config.WipeCredentials(s.with)), nil
+
+	case MethodSubscribe:
+		s.mu.Lock()
+		s.subscribers[c] = true
+		s.mu.Unlock()
+		return true, nil
+	}
+
+	return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// write sends one message as a line of JSON
+func (c *conn) write(msg message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err = c.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/control"
+)
+
+// runControl serves the control socket so the CLI and scripts go through
+// the daemon instead of writing the files themselves
+func (d *daemon) runControl(ctx context.Context) error {
+	server, err := control.Listen(d.bus, d.withManager)
+	if errors.Is(err, control.ErrInUse) {
+		d.logger.Warn("控制套接字已被其他进程占用，跳过", "error", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start control socket: %w", err)
+	}
+
+	<-ctx.Done()
+	return server.Close()
+}
+
+// withManager runs fn with exclusive access to the config manager
+func (d *daemon) withManager(fn func(m *config.Manager) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return fn(d.manager)
+}
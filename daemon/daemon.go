@@ -0,0 +1,202 @@
+// Package daemon runs the background subsystems without a GUI, for hosts
+// that have no display
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/instance"
+)
+
+// service is a background subsystem that runs until its context is cancelled
+type service struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// daemon shares the config manager between services. The manager is not
+// safe for concurrent use, so every access goes through mu.
+type daemon struct {
+	mu      sync.Mutex
+	manager *config.Manager
+	changed chan struct{} // Closed and replaced whenever settings change
+	bus     *events.Bus
+	logger  *slog.Logger
+}
+
+// Run starts all services and blocks until ctx is cancelled or the process
+// receives SIGTERM or an interrupt. SIGHUP reloads the configuration.
+func Run(ctx context.Context, logger *slog.Logger) error {
+	// Only one process may write the settings, so refuse to run beside the
+	// tray or another daemon
+	lock, err := instance.Lock()
+	if err != nil {
+		return fmt.Errorf("failed to take the instance lock: %w", err)
+	}
+	defer lock.Close()
+	lock.Serve(func(args []string) bool {
+		logger.Info("守护进程正在运行，已忽略新的启动请求", "args", args)
+		return false
+	})
+
+	manager, err := config.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize config manager: %w", err)
+	}
+
+	d := &daemon{
+		manager: manager,
+		changed: make(chan struct{}),
+		bus:     events.NewBus(),
+		logger:  logger,
+	}
+	manager.SetEventBus(d.bus)
+	manager.SetErrorReporter(d.reportSaved)
+
+	// Changes made through the control socket wake the scheduler
+	unsubscribe := d.bus.Subscribe(func(e events.Event) {
+		if _, drifted := e.(events.ClaudeSettingsDrifted); !drifted {
+			d.notifyChanged()
+		}
+	})
+	defer unsubscribe()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	services := []service{
+		{name: "watcher", run: d.runWatcher},
+		{name: "scheduler", run: d.runScheduler},
+		{name: "control", run: d.runControl},
+		{name: "webhooks", run: d.runWebhooks},
+		{name: "gateway", run: d.runGateway},
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("服务已启动", "service", s.name)
+			if err := s.run(ctx); err != nil {
+				logger.Error("服务异常退出", "service", s.name, "error", err)
+				return
+			}
+			logger.Info("服务已停止", "service", s.name)
+		}()
+	}
+
+	logger.Info("守护进程已启动", "pid", os.Getpid(), "config", manager.ConfigPath())
+	d.checkSchedules()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("正在关闭守护进程")
+			wg.Wait()
+			logger.Info("守护进程已退出")
+			return nil
+		case <-hangup:
+			d.reload()
+		}
+	}
+}
+
+// reload recreates the config manager, re-syncing it with Claude Code's
+// settings and the auto-start entry
+func (d *daemon) reload() {
+	manager, err := config.NewManager()
+	if err != nil {
+		d.logger.Error("重新加载配置失败", "error", err)
+		return
+	}
+	manager.SetEventBus(d.bus)
+	manager.SetErrorReporter(d.reportSaved)
+
+	d.mu.Lock()
+	d.manager = manager
+	d.mu.Unlock()
+
+	d.logger.Info("配置已重新加载")
+	d.notifyChanged()
+	d.checkSchedules()
+}
+
+// reloadSettings re-reads the settings file after it changed on disk,
+// keeping the previous settings if it cannot be parsed
+func (d *daemon) reloadSettings() {
+	d.mu.Lock()
+	err := d.manager.Load()
+	d.mu.Unlock()
+
+	if err != nil {
+		d.logger.Warn("读取已修改的配置失败，继续使用原配置", "error", err)
+		return
+	}
+
+	d.logger.Info("配置文件已更新")
+	d.notifyChanged()
+	d.checkSchedules()
+}
+
+// reportSaved logs a failure that followed a saved change, such as a
+// webhook that could not be queued
+func (d *daemon) reportSaved(err error) {
+	d.logger.Warn("配置已保存，但后续处理失败", "error", err)
+}
+
+// changes returns a channel that is closed the next time settings change
+func (d *daemon) changes() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.changed
+}
+
+// notifyChanged wakes every service waiting on changes
+func (d *daemon) notifyChanged() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// activate switches to the named profile unless it is already active
+func (d *daemon) activate(name, reason string) {
+	// Copy the name while holding the lock, the profile may change after it
+	d.mu.Lock()
+	activeName := ""
+	if active := d.manager.GetSettings().GetActiveProfile(); active != nil {
+		activeName = active.Name
+	}
+	d.mu.Unlock()
+
+	if activeName == name {
+		d.logger.Debug("配置已处于活动状态", "profile", name, "reason", reason)
+		return
+	}
+
+	// Hooks run without d.mu so the control socket and the gateway keep
+	// answering while they do
+	err := config.SwitchProfile(d.withManager, name)
+	if err != nil && !errors.Is(err, config.ErrPostSwitchHook) {
+		d.logger.Error("切换配置失败", "profile", name, "reason", reason, "error", err)
+		return
+	}
+	d.logger.Info("已切换配置", "profile", name, "reason", reason)
+	if err != nil {
+		d.logger.Warn("切换后钩子执行失败", "profile", name, "error", err)
+	}
+}
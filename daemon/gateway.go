@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/ipfans/cc-quick-profile/gateway"
+)
+
+// runGateway serves the local gateway while it is enabled, forwarding each
+// request to the profile active at that moment. Requests read a snapshot of
+// the settings, so they never wait for d.mu while a switch runs its hooks.
+func (d *daemon) runGateway(ctx context.Context) error {
+	snapshot := &gateway.Snapshot{}
+	update := func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		snapshot.Update(d.manager.GetSettings())
+	}
+	// Take the channel before reading so no change is missed in between
+	changed := d.changes()
+	update()
+
+	handler := gateway.NewHandler(snapshot.Resolve, func(err error) {
+		d.logger.Warn("网关转发失败", "error", err)
+	})
+	server := gateway.NewServer(handler, snapshot.Config, func(err error) {
+		d.logger.Error("网关出错", "error", err)
+	}, func(err error) {
+		d.logger.Warn("网关仍然出错", "error", err)
+	})
+
+	// Settings also change when the file is edited or reloaded, which is
+	// not announced on the bus
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+				changed = d.changes()
+				update()
+				server.Wake()
+			}
+		}
+	}()
+
+	server.Run(ctx)
+	return nil
+}
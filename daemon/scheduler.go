@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// scheduleRecheck bounds how long the scheduler sleeps, so clock changes
+// and suspends are noticed
+const scheduleRecheck = time.Minute
+
+// runScheduler activates profiles at the times configured in the settings
+func (d *daemon) runScheduler(ctx context.Context) error {
+	last := time.Now()
+	schedules := d.schedules()
+
+	for {
+		changed := d.changes()
+		next, profile, ok := nextSchedule(schedules, last)
+
+		wait := scheduleRecheck
+		if ok {
+			wait = min(wait, time.Until(next))
+		}
+		timer := time.NewTimer(max(wait, 0))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-changed:
+			timer.Stop()
+			// Edited schedules do not replay missed ones. Other changes keep
+			// last, so a schedule that fell due meanwhile still fires.
+			if current := d.schedules(); !slices.EqualFunc(current, schedules, models.Schedule.Equal) {
+				schedules = current
+				last = time.Now()
+			}
+		case now := <-timer.C:
+			if ok && !now.Before(next) {
+				d.activate(profile, "schedule")
+				last = next
+			}
+		}
+	}
+}
+
+// schedules returns a copy of the configured schedules
+func (d *daemon) schedules() []models.Schedule {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.manager.GetSettings().Schedules)
+}
+
+// nextSchedule returns the earliest of schedules firing after the given time
+func nextSchedule(schedules []models.Schedule, after time.Time) (time.Time, string, bool) {
+	var earliest time.Time
+	profile := ""
+	for _, s := range schedules {
+		next, err := s.Next(after)
+		if err != nil {
+			continue
+		}
+		if profile == "" || next.Before(earliest) {
+			earliest, profile = next, s.Profile
+		}
+	}
+	return earliest, profile, profile != ""
+}
+
+// checkSchedules logs schedules that are invalid or name unknown profiles
+func (d *daemon) checkSchedules() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	settings := d.manager.GetSettings()
+
+	for _, s := range settings.Schedules {
+		if err := s.Validate(); err != nil {
+			d.logger.Warn("忽略无效的计划", "at", s.At, "profile", s.Profile, "error", err)
+			continue
+		}
+		if settings.FindProfile(s.Profile) == nil {
+			d.logger.Warn("计划引用的配置不存在", "at", s.At, "profile", s.Profile)
+		}
+	}
+}
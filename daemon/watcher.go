@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ipfans/cc-quick-profile/events"
+)
+
+// watchDebounce groups the several events a single save usually produces
+const watchDebounce = 200 * time.Millisecond
+
+// runWatcher reloads the settings when the settings file changes and warns
+// when Claude Code's credentials drift from the active profile. Directories
+// are watched rather than files so atomic replacements are seen too.
+func (d *daemon) runWatcher(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	d.mu.Lock()
+	configPath := filepath.Clean(d.manager.ConfigPath())
+	claudePath := filepath.Clean(d.manager.ClaudeSettingsPath())
+	d.mu.Unlock()
+
+	for _, dir := range []string{filepath.Dir(configPath), filepath.Dir(claudePath)} {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	configChanged, claudeChanged := false, false
+	var settle <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			switch filepath.Clean(event.Name) {
+			case configPath:
+				configChanged = true
+			case claudePath:
+				claudeChanged = true
+			default:
+				continue
+			}
+			settle = time.After(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			d.logger.Warn("文件监视出错", "error", err)
+
+		case <-settle:
+			settle = nil
+			if configChanged {
+				d.reloadSettings()
+			}
+			if claudeChanged || configChanged {
+				d.checkDrift()
+			}
+			configChanged, claudeChanged = false, false
+		}
+	}
+}
+
+// checkDrift warns if Claude Code's settings no longer match the active profile
+func (d *daemon) checkDrift() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	settings := d.manager.GetSettings()
+	active := settings.GetActiveProfile()
+	if !settings.Enabled || active == nil {
+		return
+	}
+
+	apiKey, apiURL, err := d.manager.ClaudeAuthConfig()
+	if err != nil {
+		d.logger.Warn("读取 Claude Code 设置失败", "error", err)
+		return
+	}
+
+	wantKey, wantURL := settings.ClaudeCredentials(*active)
+	if apiKey != wantKey || apiURL != wantURL {
+		path := d.manager.ClaudeSettingsPath()
+		d.logger.Warn("Claude Code 设置与活动配置不一致", "profile", active.Name, "path", path)
+		d.bus.Publish(events.ClaudeSettingsDrifted{Profile: active.Name, Path: path})
+	}
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/webhook"
+)
+
+// runWebhooks delivers queued webhooks, checking the queue after every change.
+// A reload replaces the manager and its queue, so the sender is restarted
+// with the new queue rather than two queues claiming from one directory.
+func (d *daemon) runWebhooks(ctx context.Context) error {
+	for {
+		changed := d.changes()
+		queue := d.webhookQueue()
+
+		senderCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			d.sendWebhooks(senderCtx, queue)
+		}()
+
+		for queue == d.webhookQueue() && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-changed:
+				changed = d.changes()
+			}
+		}
+		cancel()
+		<-done
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// webhookQueue returns the current manager's queue
+func (d *daemon) webhookQueue() *webhook.Queue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.manager.WebhookQueue()
+}
+
+// sendWebhooks delivers from queue until ctx is cancelled
+func (d *daemon) sendWebhooks(ctx context.Context, queue *webhook.Queue) {
+	sender := webhook.NewSender(queue, func() []models.Webhook {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return slices.Clone(d.manager.GetSettings().Webhooks)
+	}, func(err error) {
+		d.logger.Warn("Webhook 投递失败", "error", err)
+	})
+
+	unsubscribe := d.bus.Subscribe(func(events.Event) {
+		sender.Wake()
+	})
+	defer unsubscribe()
+
+	sender.Run(ctx)
+}
@@ -0,0 +1,160 @@
+// Package deeplink parses ccqp:// links, which let a wiki or chat message
+// activate a profile or offer profiles for import
+package deeplink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// Scheme is the URL scheme handled by the application
+const Scheme = "ccqp"
+
+const (
+	// maxBundleSize bounds the decoded size of an import bundle
+	maxBundleSize = 64 << 10
+	// maxBundleProfiles bounds the number of profiles in an import bundle
+	maxBundleProfiles = 50
+)
+
+// Link is a parsed ccqp:// link: Activate or Import
+type Link interface {
+	link()
+}
+
+// Activate asks to switch to a profile, e.g. ccqp://activate?profile=work
+type Activate struct {
+	Profile string
+}
+
+// Import offers profiles to add, e.g. ccqp://import?bundle=<base64>. Profiles
+// without a key must be completed by the user before they can be saved.
+type Import struct {
+	Profiles []models.Profile
+}
+
+func (Activate) link() {}
+func (Import) link()   {}
+
+// bundleProfile is the only profile data a bundle can carry. Hooks and other
+// settings are deliberately not accepted from links.
+type bundleProfile struct {
+	Name   string `json:"name"`
+	APIURL string `json:"apiUrl"`
+	APIKey string `json:"apiKey,omitempty"`
+	Color  string `json:"color,omitempty"`
+}
+
+// bundle is the JSON encoded in the bundle parameter
+type bundle struct {
+	Profiles []bundleProfile `json:"profiles"`
+}
+
+// Parse validates a ccqp:// link and returns what it asks for
+func Parse(raw string) (Link, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, Scheme) {
+		return nil, fmt.Errorf("unsupported link scheme: %s", u.Scheme)
+	}
+
+	// Accept both ccqp://activate?… and ccqp:activate?…
+	action := u.Host
+	if action == "" {
+		action = u.Opaque
+	}
+	if strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("unexpected path in link: %s", u.Path)
+	}
+
+	query := u.Query()
+	switch strings.ToLower(action) {
+	case "activate":
+		name := strings.TrimSpace(query.Get("profile"))
+		if name == "" {
+			return nil, errors.New("activate link has no profile")
+		}
+		return Activate{Profile: name}, nil
+
+	case "import":
+		profiles, err := decodeBundle(query.Get("bundle"))
+		if err != nil {
+			return nil, err
+		}
+		return Import{Profiles: profiles}, nil
+	}
+
+	return nil, fmt.Errorf("unknown link action: %q", action)
+}
+
+// decodeBundle decodes and validates the profiles of an import bundle. Both
+// URL-safe and standard base64 are accepted, with or without padding.
+func decodeBundle(encoded string) ([]models.Profile, error) {
+	// An unescaped "+" of standard base64 arrives as a space
+	encoded = strings.ReplaceAll(strings.TrimSpace(encoded), " ", "+")
+	encoded = strings.TrimRight(encoded, "=")
+	if encoded == "" {
+		return nil, errors.New("import link has no bundle")
+	}
+	if base64.RawURLEncoding.DecodedLen(len(encoded)) > maxBundleSize {
+		return nil, errors.New("import bundle is too large")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("invalid import bundle encoding: %w", err)
+		}
+	}
+
+	var b bundle
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid import bundle: %w", err)
+	}
+	if len(b.Profiles) == 0 {
+		return nil, errors.New("import bundle contains no profiles")
+	}
+	if len(b.Profiles) > maxBundleProfiles {
+		return nil, fmt.Errorf("import bundle contains more than %d profiles", maxBundleProfiles)
+	}
+
+	profiles := []models.Profile{}
+	seen := map[string]bool{}
+	for _, bp := range b.Profiles {
+		p := models.Profile{
+			Name:   strings.TrimSpace(bp.Name),
+			APIURL: strings.TrimSpace(bp.APIURL),
+			APIKey: strings.TrimSpace(bp.APIKey),
+			Color:  bp.Color,
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("import bundle contains profile %q twice", p.Name)
+		}
+		seen[p.Name] = true
+
+		// The key may be left for the user to enter
+		check := p
+		if check.APIKey == "" {
+			check.APIKey = "placeholder"
+		}
+		if err := check.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid profile %q in import bundle: %w", p.Name, err)
+		}
+		if u, err := url.Parse(p.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid profile %q in import bundle: API URL must be an http or https address", p.Name)
+		}
+
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
@@ -0,0 +1,108 @@
+package deeplink
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// encode builds a bundle parameter from raw JSON
+func encode(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+func TestParseActivate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Link
+	}{
+		{"ccqp://activate?profile=work", Activate{Profile: "work"}},
+		{"ccqp://activate/?profile=work", Activate{Profile: "work"}},
+		{"ccqp:activate?profile=work", Activate{Profile: "work"}},
+		{"CCQP://ACTIVATE?profile=%20work%20", Activate{Profile: "work"}},
+		{"ccqp://activate?profile=%E5%B7%A5%E4%BD%9C", Activate{Profile: "工作"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.raw)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	data := `{"profiles":[{"name":" work ","apiUrl":"https://api.example.com","apiKey":"sk-1","color":"red"},{"name":"home","apiUrl":"http://localhost:8080"}]}`
+	want := Import{Profiles: []models.Profile{
+		{Name: "work", APIURL: "https://api.example.com", APIKey: "sk-1", Color: "red"},
+		{Name: "home", APIURL: "http://localhost:8080"},
+	}}
+
+	std := base64.StdEncoding.EncodeToString([]byte(data))
+	for _, bundle := range []string{
+		encode(data),
+		base64.URLEncoding.EncodeToString([]byte(data)),
+		std,
+		// An unescaped "+" arrives as a space
+		strings.ReplaceAll(std, "+", " "),
+	} {
+		got, err := Parse("ccqp://import?bundle=" + bundle)
+		if err != nil {
+			t.Errorf("Parse(bundle %q) error = %v", bundle, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(bundle %q) = %#v, want %#v", bundle, got, want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	profile := func(name, url string) string {
+		return fmt.Sprintf(`{"name":%q,"apiUrl":%q}`, name, url)
+	}
+	many := make([]string, maxBundleProfiles+1)
+	for i := range many {
+		many[i] = profile(fmt.Sprintf("p%d", i), "https://example.com")
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"other scheme", "https://activate?profile=work", "unsupported link scheme"},
+		{"unknown action", "ccqp://delete?profile=work", "unknown link action"},
+		{"path", "ccqp://activate/extra?profile=work", "unexpected path"},
+		{"no profile", "ccqp://activate?profile=%20", "has no profile"},
+		{"no bundle", "ccqp://import", "has no bundle"},
+		{"oversized bundle", "ccqp://import?bundle=" + strings.Repeat("A", maxBundleSize*4/3+8), "too large"},
+		{"bad encoding", "ccqp://import?bundle=***", "invalid import bundle encoding"},
+		{"not JSON", "ccqp://import?bundle=" + encode("profiles"), "invalid import bundle"},
+		{"unknown field", "ccqp://import?bundle=" + encode(`{"profiles":[{"name":"a","apiUrl":"https://a","hooks":{}}]}`), "unknown field"},
+		{"empty bundle", "ccqp://import?bundle=" + encode(`{"profiles":[]}`), "no profiles"},
+		{"too many profiles", "ccqp://import?bundle=" + encode(`{"profiles":[`+strings.Join(many, ",")+`]}`), "more than"},
+		{"duplicate profile", "ccqp://import?bundle=" + encode(`{"profiles":[`+profile("a", "https://a")+`,`+profile(" a", "https://b")+`]}`), "twice"},
+		{"missing name", "ccqp://import?bundle=" + encode(`{"profiles":[`+profile("", "https://a")+`]}`), "invalid profile"},
+		{"file URL", "ccqp://import?bundle=" + encode(`{"profiles":[`+profile("a", "file:///etc/passwd")+`]}`), "http or https"},
+		{"javascript URL", "ccqp://import?bundle=" + encode(`{"profiles":[`+profile("a", "javascript:alert(1)")+`]}`), "http or https"},
+		{"URL without host", "ccqp://import?bundle=" + encode(`{"profiles":[`+profile("a", "https:///v1")+`]}`), "http or https"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.raw)
+		if err == nil {
+			t.Errorf("%s: Parse() = %#v, want error", tt.name, got)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Parse() error = %q, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
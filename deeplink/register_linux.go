@@ -0,0 +1,154 @@
+//go:build linux
+
+package deeplink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// desktopFileName is the handler's desktop entry, separate from the
+// auto-start entry so it does not launch at login
+const desktopFileName = "cc-quick-profile-url.desktop"
+
+// mimeType is the MIME type desktop environments use for the scheme
+const mimeType = "x-scheme-handler/" + Scheme
+
+// Register makes executable the handler for ccqp:// links by writing a
+// desktop entry to the applications directory and setting it as the default
+// in mimeapps.list. It is safe to call on every start.
+func Register(executable string) error {
+	dataHome, configHome, err := xdgDirs()
+	if err != nil {
+		return err
+	}
+
+	appDir := filepath.Join(dataHome, "applications")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		return fmt.Errorf("failed to create applications directory: %w", err)
+	}
+
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=CC Quick Profile
+Comment=Open ccqp:// links with CC Quick Profile
+Exec=%s %%u
+NoDisplay=true
+Terminal=false
+MimeType=%s;
+`, quoteExec(executable), mimeType)
+	if err := os.WriteFile(filepath.Join(appDir, desktopFileName), []byte(entry), 0644); err != nil {
+		return fmt.Errorf("failed to write desktop file: %w", err)
+	}
+
+	if err := os.MkdirAll(configHome, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return setDefault(filepath.Join(configHome, "mimeapps.list"))
+}
+
+// xdgDirs returns the XDG data and config directories
+func xdgDirs() (dataHome, configHome string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	dataHome = os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataHome) {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome = os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(configHome) {
+		configHome = filepath.Join(home, ".config")
+	}
+	return dataHome, configHome, nil
+}
+
+// setDefault sets the scheme's default handler in a mimeapps.list file,
+// keeping every other line
+func setDefault(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	const section = "[Default Applications]"
+	entry := mimeType + "=" + desktopFileName
+
+	lines := []string{}
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	// Leave the file alone, including its modification time, if the scheme
+	// already opens with this handler
+	if isDefault(lines, section) {
+		return nil
+	}
+
+	inSection, sectionFound, written := false, false, false
+	out := []string{}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inSection && !written {
+				out = append(out, entry)
+				written = true
+			}
+			inSection = trimmed == section
+			sectionFound = sectionFound || inSection
+		} else if inSection && strings.HasPrefix(trimmed, mimeType+"=") {
+			if !written {
+				out = append(out, entry)
+				written = true
+			}
+			continue
+		}
+		out = append(out, line)
+	}
+	if !written {
+		if !sectionFound {
+			if len(out) > 0 {
+				out = append(out, "")
+			}
+			out = append(out, section)
+		}
+		out = append(out, entry)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(out, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// isDefault reports whether the section of a mimeapps.list file names this
+// handler first for the scheme
+func isDefault(lines []string, section string) bool {
+	inSection := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inSection = trimmed == section
+			continue
+		}
+		if value, ok := strings.CutPrefix(trimmed, mimeType+"="); inSection && ok {
+			first, _, _ := strings.Cut(value, ";")
+			return strings.TrimSpace(first) == desktopFileName
+		}
+	}
+	return false
+}
+
+// quoteExec quotes a path for the Exec key of a desktop entry
+func quoteExec(path string) string {
+	path = strings.ReplaceAll(path, "%", "%%")
+	if !strings.ContainsAny(path, " \t\"'\\`$") {
+		return path
+	}
+	replacer := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`)
+	return `"` + replacer.Replace(path) + `"`
+}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package deeplink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetDefault(t *testing.T) {
+	entry := mimeType + "=" + desktopFileName
+
+	tests := []struct {
+		name    string
+		content string // Empty for a missing file
+		want    string
+	}{
+		{"missing file", "", "[Default Applications]\n" + entry + "\n"},
+		{"other section", "[Added Associations]\ntext/plain=a.desktop\n",
+			"[Added Associations]\ntext/plain=a.desktop\n\n[Default Applications]\n" + entry + "\n"},
+		{"other handler", "[Default Applications]\n" + mimeType + "=other.desktop\ntext/plain=a.desktop\n",
+			"[Default Applications]\n" + entry + "\ntext/plain=a.desktop\n"},
+		{"added to section", "[Default Applications]\ntext/plain=a.desktop\n[Added Associations]\n",
+			"[Default Applications]\ntext/plain=a.desktop\n" + entry + "\n[Added Associations]\n"},
+		{"already default", "# kept as is\n[Default Applications]\n  " + entry + ";other.desktop;\n",
+			"# kept as is\n[Default Applications]\n  " + entry + ";other.desktop;\n"},
+		{"default elsewhere only", "[Added Associations]\n" + entry + "\n",
+			"[Added Associations]\n" + entry + "\n\n[Default Applications]\n" + entry + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mimeapps.list")
+			old := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := setDefault(path); err != nil {
+				t.Fatalf("setDefault() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("setDefault() wrote %q, want %q", data, tt.want)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unchanged := tt.content == tt.want; unchanged != info.ModTime().Equal(old) {
+				t.Errorf("modification time = %v, want it changed only if the content was", info.ModTime())
+			}
+		})
+	}
+}
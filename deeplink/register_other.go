@@ -0,0 +1,11 @@
+//go:build !linux
+
+package deeplink
+
+import "errors"
+
+// Register is only implemented on Linux. macOS declares URL schemes in the
+// app bundle's Info.plist and Windows in the registry at install time.
+func Register(executable string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,104 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Operation is a single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// pointerEscaper escapes object keys for use in a JSON Pointer
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPatch returns the operations that turn document a into document b.
+// Objects are compared key by key; changed arrays are replaced whole.
+func JSONPatch(a, b []byte) ([]Operation, error) {
+	before, err := decode(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse original document: %w", err)
+	}
+	after, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse updated document: %w", err)
+	}
+
+	ops := []Operation{}
+	if err := compare("", before, after, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// decode parses a JSON document, keeping numbers exactly as written
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// compare appends the operations turning x into y at path
+func compare(path string, x, y interface{}, ops *[]Operation) error {
+	xObj, xIsObj := x.(map[string]interface{})
+	yObj, yIsObj := y.(map[string]interface{})
+	if !xIsObj || !yIsObj {
+		if reflect.DeepEqual(x, y) {
+			return nil
+		}
+		return appendOp(ops, "replace", path, y)
+	}
+
+	keys := []string{}
+	for k := range xObj {
+		keys = append(keys, k)
+	}
+	for k := range yObj {
+		if _, ok := xObj[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "/" + pointerEscaper.Replace(k)
+		xv, inX := xObj[k]
+		yv, inY := yObj[k]
+
+		var err error
+		switch {
+		case !inY:
+			*ops = append(*ops, Operation{Op: "remove", Path: child})
+		case !inX:
+			err = appendOp(ops, "add", child, yv)
+		default:
+			err = compare(child, xv, yv, ops)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendOp appends an operation carrying value
+func appendOp(ops *[]Operation, op, path string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value at %s: %w", path, err)
+	}
+	*ops = append(*ops, Operation{Op: op, Path: path, Value: raw})
+	return nil
+}
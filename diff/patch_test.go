@@ -0,0 +1,49 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string // The operations encoded as JSON
+	}{
+		{"equal", `{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, `[]`},
+		{"numbers kept as written", `{"a":1.0}`, `{"a":1.00}`, `[{"op":"replace","path":"/a","value":1.00}]`},
+		{"add", `{}`, `{"env":{"K":"v"}}`, `[{"op":"add","path":"/env","value":{"K":"v"}}]`},
+		{"remove", `{"env":{"K":"v","L":"w"}}`, `{"env":{"L":"w"}}`, `[{"op":"remove","path":"/env/K"}]`},
+		{"nested replace", `{"env":{"K":"v"}}`, `{"env":{"K":"w"}}`, `[{"op":"replace","path":"/env/K","value":"w"}]`},
+		{"arrays replaced whole", `{"a":[1,2]}`, `{"a":[1,3]}`, `[{"op":"replace","path":"/a","value":[1,3]}]`},
+		{"type change", `{"a":{"b":1}}`, `{"a":"b"}`, `[{"op":"replace","path":"/a","value":"b"}]`},
+		{"keys in order", `{"b":1,"a":1}`, `{"c":1}`,
+			`[{"op":"remove","path":"/a"},{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":1}]`},
+		{"escaped pointer", `{}`, `{"a/b~c":null}`, `[{"op":"add","path":"/a~1b~0c","value":null}]`},
+		{"root", `[1]`, `[2]`, `[{"op":"replace","path":"","value":[2]}]`},
+	}
+	for _, tt := range tests {
+		ops, err := JSONPatch([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Errorf("%s: JSONPatch() error = %v", tt.name, err)
+			continue
+		}
+		got, err := json.Marshal(ops)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: JSONPatch() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPatchInvalid(t *testing.T) {
+	if _, err := JSONPatch([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("JSONPatch() with an invalid original succeeded, want error")
+	}
+	if _, err := JSONPatch([]byte(`{}`), []byte(`nope`)); err == nil {
+		t.Error("JSONPatch() with an invalid update succeeded, want error")
+	}
+}
@@ -0,0 +1,137 @@
+// Package diff compares settings files for previews
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// edit is one line of an edit script: ' ' kept, '-' removed or '+' added
+type edit struct {
+	kind byte
+	line string
+}
+
+// Unified returns a unified diff of two texts, or "" if they are equal
+func Unified(oldName, newName string, a, b []byte) string {
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	changes := []int{}
+	for i, e := range edits {
+		if e.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for first := 0; first < len(changes); {
+		// Merge changes whose context would overlap or touch into one hunk
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*contextLines+1 {
+			last++
+		}
+
+		start := max(changes[first]-contextLines, 0)
+		end := min(changes[last]+contextLines+1, len(edits))
+		writeHunk(&out, edits, start, end)
+		first = last + 1
+	}
+
+	return out.String()
+}
+
+// writeHunk writes edits[start:end] with its @@ header
+func writeHunk(out *strings.Builder, edits []edit, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, e := range edits[:start] {
+		if e.kind != '+' {
+			oldLine++
+		}
+		if e.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, e := range edits[start:end] {
+		if e.kind != '+' {
+			oldCount++
+		}
+		if e.kind != '-' {
+			newCount++
+		}
+	}
+
+	// An empty range is numbered by the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, e := range edits[start:end] {
+		fmt.Fprintf(out, "%c%s\n", e.kind, e.line)
+	}
+}
+
+// splitLines splits text into lines without their terminators
+func splitLines(text []byte) []string {
+	s := strings.TrimSuffix(strings.ReplaceAll(string(text), "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the shortest edit script turning a into b, found
+// through their longest common subsequence. Settings files are small, so
+// the quadratic table is not a concern.
+func diffLines(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+	return edits
+}
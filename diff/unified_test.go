@@ -0,0 +1,42 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"line endings only", "a\r\nb\r\n", "a\nb", ""},
+		{"empty to one line", "", "x\n",
+			"@@ -0,0 +1,1 @@\n+x\n"},
+		{"one line to empty", "x\n", "",
+			"@@ -1,1 +0,0 @@\n-x\n"},
+		{"change in the middle",
+			"a\nb\nc\nd\ne\nf\ng\nh\ni\n", "a\nb\nc\nd\nE\nf\ng\nh\ni\n",
+			"@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n"},
+		{"append at the end", "a\nb\n", "a\nb\nc\n",
+			"@@ -1,2 +1,3 @@\n a\n b\n+c\n"},
+		{"nearby changes share a hunk",
+			"a\nb\nc\nd\ne\nf\ng\nh\n", "A\nb\nc\nd\ne\nf\ng\nH\n",
+			"@@ -1,8 +1,8 @@\n-a\n+A\n b\n c\n d\n e\n f\n g\n-h\n+H\n"},
+		{"changes further apart get their own hunks",
+			"a\nb\nc\nd\ne\nf\ng\nh\ni\n", "A\nb\nc\nd\ne\nf\ng\nh\nI\n",
+			"@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n@@ -6,4 +6,4 @@\n f\n g\n h\n-i\n+I\n"},
+		{"distant changes get their own hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n",
+			"1\n2\nX\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\nY\n16\n",
+			"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+X\n 4\n 5\n 6\n@@ -12,5 +12,5 @@\n 12\n 13\n 14\n-15\n+Y\n 16\n"},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		if want != "" {
+			want = "--- old\n+++ new\n" + want
+		}
+		if got := Unified("old", "new", []byte(tt.a), []byte(tt.b)); got != want {
+			t.Errorf("%s: Unified() =\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Package doctor diagnoses why a profile switch may not take effect
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/autostart"
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+	"github.com/tidwall/gjson"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	// StatusOK means nothing needs attention
+	StatusOK Status = iota
+	// StatusWarning means a likely cause of surprising behaviour
+	StatusWarning
+	// StatusError means switching profiles cannot work as expected
+	StatusError
+)
+
+// Check is the result of a single diagnostic
+type Check struct {
+	Name   string // What was checked
+	Status Status
+	Detail string // What was found
+	Hint   string // How to fix it, empty for passing checks
+}
+
+// Problem reports whether the check did not pass
+func (c Check) Problem() bool {
+	return c.Status != StatusOK
+}
+
+// Run performs all diagnostics. It only reads files and the environment, so
+// it works even when the configuration cannot be loaded.
+func Run() []Check {
+	checks := []Check{}
+
+	settings, configChecks := checkConfig()
+	checks = append(checks, configChecks...)
+
+	claudeData, claudeChecks := checkClaudeSettings()
+	checks = append(checks, claudeChecks...)
+
+	if settings != nil {
+		checks = append(checks, checkProfiles(settings))
+		if len(settings.Webhooks) > 0 {
+			checks = append(checks, checkWebhooks(settings.Webhooks))
+		}
+		if settings.Gateway.Enabled {
+			checks = append(checks, checkGateway(settings.Gateway))
+		}
+		if claudeData != nil {
+			checks = append(checks, checkSync(settings, claudeData))
+		}
+	}
+
+	checks = append(checks, checkAutostart())
+	checks = append(checks, checkEnvironment(settings, os.Environ())...)
+	return checks
+}
+
+// checkConfig checks the application settings file and returns its
+// contents if it could be parsed
+func checkConfig() (*models.Settings, []Check) {
+	const name = "配置文件"
+
+	path, err := config.Path()
+	if err != nil {
+		return nil, []Check{{Name: name, Status: StatusError, Detail: err.Error(), Hint: "确认主目录可访问"}}
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, []Check{{Name: name, Status: StatusWarning, Detail: path + " 尚未创建",
+			Hint: "运行 cc-quick-profile add 添加第一个配置"}}
+	}
+	if err != nil {
+		return nil, []Check{{Name: name, Status: StatusError, Detail: err.Error(), Hint: "检查文件所有者和权限"}}
+	}
+
+	settings := &models.Settings{}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, []Check{{Name: name, Status: StatusError, Detail: path + ": " + describeJSONError(data, err),
+			Hint: "修复该文件中的 JSON 语法，或备份后删除并重新添加配置"}}
+	}
+
+	checks := []Check{{Name: name, Detail: path}}
+	if perm := checkPermissions(name+"权限", path); perm != nil {
+		checks = append(checks, *perm)
+	}
+	return settings, checks
+}
+
+// checkClaudeSettings checks Claude Code's settings file and returns its
+// contents if it is valid JSON
+func checkClaudeSettings() ([]byte, []Check) {
+	const name = "Claude Code 设置"
+
+	path, err := claude.DefaultSettingsPath()
+	if err != nil {
+		return nil, []Check{{Name: name, Status: StatusError, Detail: err.Error(), Hint: "确认主目录可访问"}}
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, []Check{{Name: name, Status: StatusWarning, Detail: path + " 不存在",
+			Hint: "运行 cc-quick-profile use <名称> 或 enable 后会自动创建"}}
+	}
+	if err != nil {
+		return nil, []Check{{Name: name, Status: StatusError, Detail: err.Error(), Hint: "检查文件所有者和权限"}}
+	}
+
+	if !json.Valid(data) {
+		var v interface{}
+		return nil, []Check{{Name: name, Status: StatusError, Detail: path + ": " + describeJSONError(data, json.Unmarshal(data, &v)),
+			Hint: "修复该文件中的 JSON 语法，否则 Claude Code 和本程序都无法读取凭据"}}
+	}
+
+	checks := []Check{{Name: name, Detail: path}}
+	if perm := checkPermissions(name+"权限", path); perm != nil {
+		checks = append(checks, *perm)
+	}
+	return data, checks
+}
+
+// checkPermissions reports files that cannot be written or that other users
+// can read. It returns nil on Windows, where modes do not reflect ACLs.
+func checkPermissions(name, path string) *Check {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return &Check{Name: name, Status: StatusError, Detail: err.Error(), Hint: "检查文件所有者和权限"}
+	}
+
+	mode := info.Mode().Perm()
+	switch {
+	case mode&0200 == 0:
+		return &Check{Name: name, Status: StatusError, Detail: fmt.Sprintf("%04o，文件只读，切换配置时无法写入", mode),
+			Hint: "chmod u+w " + path}
+	case mode&0077 != 0:
+		return &Check{Name: name, Status: StatusWarning, Detail: fmt.Sprintf("%04o，其他用户可以读取其中的 API 密钥", mode),
+			Hint: "chmod 600 " + path}
+	}
+	return &Check{Name: name, Detail: fmt.Sprintf("%04o", mode)}
+}
+
+// checkProfiles reports duplicate names and invalid profiles
+func checkProfiles(settings *models.Settings) Check {
+	const name = "配置列表"
+
+	problems := []string{}
+	seen := map[string]bool{}
+	reported := map[string]bool{}
+	active := 0
+	for _, p := range settings.Profiles {
+		if seen[p.Name] && !reported[p.Name] {
+			problems = append(problems, fmt.Sprintf("配置名称 '%s' 重复", p.Name))
+			reported[p.Name] = true
+		}
+		seen[p.Name] = true
+
+		if err := p.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("配置 '%s': %v", p.Name, err))
+		}
+		if p.Active {
+			active++
+		}
+	}
+	if active > 1 {
+		problems = append(problems, fmt.Sprintf("有 %d 个配置同时标记为活动", active))
+	}
+
+	if len(problems) > 0 {
+		return Check{Name: name, Status: StatusError, Detail: strings.Join(problems, "; "),
+			Hint: "用 cc-quick-profile edit 重命名或补全配置，再用 use 重新选择活动配置"}
+	}
+	return Check{Name: name, Detail: fmt.Sprintf("%d 个配置", len(settings.Profiles))}
+}
+
+// checkWebhooks reports webhooks with invalid URLs or event names
+func checkWebhooks(webhooks []models.Webhook) Check {
+	const name = "Webhook"
+
+	problems := []string{}
+	for _, w := range webhooks {
+		if err := w.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", redact.String(w.URL), err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return Check{Name: name, Status: StatusError, Detail: strings.Join(problems, "; "),
+			Hint: "在配置文件的 webhooks 中修正这些条目"}
+	}
+	return Check{Name: name, Detail: fmt.Sprintf("%d 个目标", len(webhooks))}
+}
+
+// checkGateway verifies that the gateway has a token and only listens on the
+// loopback interface
+func checkGateway(gateway models.Gateway) Check {
+	const name = "本地网关"
+
+	if err := gateway.Validate(); err != nil {
+		return Check{Name: name, Status: StatusError, Detail: err.Error(),
+			Hint: "将配置文件中 gateway.listen 改为 127.0.0.1:<端口>"}
+	}
+	if gateway.Token == "" {
+		return Check{Name: name, Status: StatusError, Detail: "缺少网关令牌，所有请求都会被拒绝",
+			Hint: "运行 cc-quick-profile gateway on 生成令牌"}
+	}
+	return Check{Name: name, Detail: "监听 " + gateway.URL()}
+}
+
+// checkSync compares the credentials in Claude Code's settings with the
+// active profile
+func checkSync(settings *models.Settings, claudeData []byte) Check {
+	const name = "凭据同步"
+
+	token := gjson.GetBytes(claudeData, "env."+claude.EnvAuthToken).String()
+	baseURL := gjson.GetBytes(claudeData, "env."+claude.EnvBaseURL).String()
+	active := settings.GetActiveProfile()
+
+	if !settings.Enabled {
+		if token != "" || baseURL != "" {
+			return Check{Name: name, Status: StatusWarning, Detail: "已禁用，但 Claude Code 设置中仍有凭据",
+				Hint: "运行 cc-quick-profile disable 移除残留凭据"}
+		}
+		return Check{Name: name, Detail: "已禁用，Claude Code 设置中没有凭据"}
+	}
+
+	if active == nil {
+		return Check{Name: name, Status: StatusWarning, Detail: "已启用，但没有活动配置",
+			Hint: "运行 cc-quick-profile use <名称> 选择活动配置"}
+	}
+
+	wantToken, wantURL := settings.ClaudeCredentials(*active)
+	mismatched := []string{}
+	if token != wantToken {
+		mismatched = append(mismatched, claude.EnvAuthToken)
+	}
+	if baseURL != wantURL {
+		mismatched = append(mismatched, claude.EnvBaseURL)
+	}
+	if len(mismatched) > 0 {
+		return Check{Name: name, Status: StatusError,
+			Detail: fmt.Sprintf("Claude Code 设置中的 %s 与活动配置 '%s' 不一致", strings.Join(mismatched, " 和 "), active.Name),
+			Hint:   fmt.Sprintf("运行 cc-quick-profile use %s 重新写入", shellenv.Quote(shellenv.Posix, active.Name))}
+	}
+	return Check{Name: name, Detail: fmt.Sprintf("与活动配置 '%s' 一致", active.Name)}
+}
+
+// checkAutostart verifies that an enabled auto-start entry launches this executable
+func checkAutostart() Check {
+	const name = "开机自启"
+
+	executable, err := os.Executable()
+	if err != nil {
+		return Check{Name: name, Status: StatusWarning, Detail: err.Error()}
+	}
+
+	manager, err := autostart.NewManager(config.AppName, executable)
+	if err != nil {
+		return Check{Name: name, Status: StatusWarning, Detail: err.Error()}
+	}
+
+	target, err := manager.Target()
+	if err != nil {
+		return Check{Name: name, Status: StatusWarning, Detail: err.Error(),
+			Hint: "在托盘菜单中关闭并重新开启开机自启"}
+	}
+	if target == "" {
+		return Check{Name: name, Detail: "未开启"}
+	}
+
+	if !samePath(target, executable) {
+		return Check{Name: name, Status: StatusError,
+			Detail: fmt.Sprintf("自启项指向 %s，而当前程序位于 %s", target, executable),
+			Hint:   "在托盘菜单中关闭并重新开启开机自启"}
+	}
+	return Check{Name: name, Detail: "指向 " + target}
+}
+
+// samePath reports whether two paths name the same file after resolving links
+func samePath(a, b string) bool {
+	resolve := func(p string) string {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		return filepath.Clean(p)
+	}
+
+	a, b = resolve(a), resolve(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// checkEnvironment reports ANTHROPIC_* variables in the process environment
+// that override or conflict with the active profile
+func checkEnvironment(settings *models.Settings, environ []string) []Check {
+	const name = "环境变量"
+
+	// Compare against what Claude Code is pointed at, which is the gateway
+	// rather than the profile while the gateway is enabled
+	var wantToken, wantURL string
+	if settings != nil {
+		if active := settings.GetActiveProfile(); active != nil {
+			wantToken, wantURL = settings.ClaudeCredentials(*active)
+		}
+	}
+
+	checks := []Check{}
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+
+		var expected string
+		switch key {
+		case claude.EnvAuthToken:
+			expected = wantToken
+		case claude.EnvBaseURL:
+			expected = wantURL
+		case "ANTHROPIC_API_KEY":
+			checks = append(checks, Check{Name: name, Status: StatusWarning,
+				Detail: fmt.Sprintf("已设置 ANTHROPIC_API_KEY=%s，会与 %s 产生认证冲突", redact.MaskKey(value), claude.EnvAuthToken),
+				Hint:   "unset ANTHROPIC_API_KEY"})
+			continue
+		default:
+			continue
+		}
+
+		if value == expected {
+			continue
+		}
+		shown := redact.String(value)
+		if key == claude.EnvAuthToken {
+			shown = redact.MaskKey(value)
+		}
+		checks = append(checks, Check{Name: name, Status: StatusWarning,
+			Detail: fmt.Sprintf("已设置 %s=%s，与活动配置不一致，可能覆盖切换结果", key, shown),
+			Hint:   "从 shell 配置中移除该变量，或运行 eval \"$(cc-quick-profile env -unset)\""})
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, Check{Name: name, Detail: "没有冲突的 ANTHROPIC_* 变量"})
+	}
+	return checks
+}
+
+// describeJSONError adds the line number to a JSON syntax error
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := bytes.Count(data[:min(int(syntaxErr.Offset), len(data))], []byte("\n")) + 1
+		return fmt.Sprintf("JSON 无效 (第 %d 行): %v", line, err)
+	}
+	return fmt.Sprintf("JSON 无效: %v", err)
+}
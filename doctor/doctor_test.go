@@ -0,0 +1,219 @@
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// sandboxHome points the config and Claude settings paths at a temporary
+// home directory
+func sandboxHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", filepath.Join(home, "AppData"))
+	return home
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func claudeSettings(token, baseURL string) []byte {
+	env := map[string]string{}
+	if token != "" {
+		env[claude.EnvAuthToken] = token
+	}
+	if baseURL != "" {
+		env[claude.EnvBaseURL] = baseURL
+	}
+	data, _ := json.Marshal(map[string]interface{}{"env": env})
+	return data
+}
+
+func TestCheckSync(t *testing.T) {
+	work := models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: "sk-work-0123456789", Active: true}
+	gateway := models.Gateway{Enabled: true, Token: "gw-token-0123456789"}
+
+	tests := []struct {
+		name     string
+		settings models.Settings
+		claude   []byte
+		want     Status
+		detail   string // Substring expected in the detail
+	}{
+		{"disabled and clean", models.Settings{Profiles: []models.Profile{work}}, claudeSettings("", ""), StatusOK, "已禁用"},
+		{"disabled with leftovers", models.Settings{Profiles: []models.Profile{work}}, claudeSettings(work.APIKey, work.APIURL), StatusWarning, "仍有凭据"},
+		{"no active profile", models.Settings{Enabled: true}, claudeSettings("", ""), StatusWarning, "没有活动配置"},
+		{"in sync", models.Settings{Enabled: true, Profiles: []models.Profile{work}}, claudeSettings(work.APIKey, work.APIURL), StatusOK, "一致"},
+		{"gateway on", models.Settings{Enabled: true, Profiles: []models.Profile{work}, Gateway: gateway},
+			claudeSettings(gateway.Token, gateway.URL()), StatusOK, "一致"},
+		{"gateway on with profile key", models.Settings{Enabled: true, Profiles: []models.Profile{work}, Gateway: gateway},
+			claudeSettings(work.APIKey, work.APIURL), StatusError, claude.EnvAuthToken + " 和 " + claude.EnvBaseURL},
+		{"token mismatch", models.Settings{Enabled: true, Profiles: []models.Profile{work}},
+			claudeSettings("sk-other-0123456789", work.APIURL), StatusError, claude.EnvAuthToken + " 与"},
+		{"url mismatch", models.Settings{Enabled: true, Profiles: []models.Profile{work}},
+			claudeSettings(work.APIKey, "https://other.example.com"), StatusError, claude.EnvBaseURL + " 与"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkSync(&tt.settings, tt.claude)
+			if got.Status != tt.want {
+				t.Errorf("checkSync() status = %v, want %v (%s)", got.Status, tt.want, got.Detail)
+			}
+			if !strings.Contains(got.Detail, tt.detail) {
+				t.Errorf("checkSync() detail = %q, want it to contain %q", got.Detail, tt.detail)
+			}
+			if got.Problem() && got.Hint == "" {
+				t.Error("checkSync() reported a problem without a hint")
+			}
+		})
+	}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	const key = "sk-env-0123456789abcdef"
+	work := models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: key, Active: true}
+	settings := &models.Settings{Enabled: true, Profiles: []models.Profile{work}}
+
+	tests := []struct {
+		name     string
+		settings *models.Settings
+		environ  []string
+		warnings int
+	}{
+		{"nothing set", settings, []string{"PATH=/bin", "HOME=/home/u"}, 0},
+		{"matches the active profile", settings, []string{claude.EnvAuthToken + "=" + key, claude.EnvBaseURL + "=" + work.APIURL}, 0},
+		{"stale token", settings, []string{claude.EnvAuthToken + "=sk-stale-0123456789abcdef"}, 1},
+		{"stale token and url", settings, []string{claude.EnvAuthToken + "=sk-stale-0123456789abcdef", claude.EnvBaseURL + "=https://old.example.com"}, 2},
+		{"api key conflicts", settings, []string{"ANTHROPIC_API_KEY=" + key}, 1},
+		{"no settings", nil, []string{claude.EnvBaseURL + "=https://api.example.com"}, 1},
+		{"unrelated anthropic variable", settings, []string{"ANTHROPIC_MODEL=opus"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := checkEnvironment(tt.settings, tt.environ)
+			warnings := 0
+			for _, c := range checks {
+				if c.Problem() {
+					warnings++
+				}
+				if strings.Contains(c.Detail, key) {
+					t.Errorf("checkEnvironment() detail %q contains the API key", c.Detail)
+				}
+			}
+			if warnings != tt.warnings {
+				t.Errorf("checkEnvironment() = %+v, want %d warnings", checks, tt.warnings)
+			}
+			if tt.warnings == 0 && len(checks) != 1 {
+				t.Errorf("checkEnvironment() returned %d checks, want a single passing check", len(checks))
+			}
+		})
+	}
+}
+
+func TestCheckProfiles(t *testing.T) {
+	profile := func(name string, active bool) models.Profile {
+		return models.Profile{Name: name, APIURL: "https://api.example.com", APIKey: "sk-" + name + "-0123456789", Active: active}
+	}
+
+	tests := []struct {
+		name     string
+		profiles []models.Profile
+		want     Status
+		detail   string
+	}{
+		{"empty", nil, StatusOK, "0 个配置"},
+		{"valid", []models.Profile{profile("a", true), profile("b", false)}, StatusOK, "2 个配置"},
+		{"duplicate names", []models.Profile{profile("a", false), profile("a", false), profile("a", false)}, StatusError, "'a' 重复"},
+		{"multiple active", []models.Profile{profile("a", true), profile("b", true)}, StatusError, "2 个配置同时标记为活动"},
+		{"invalid profile", []models.Profile{{Name: "bad", APIURL: "https://api.example.com"}}, StatusError, "配置 'bad'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkProfiles(&models.Settings{Profiles: tt.profiles})
+			if got.Status != tt.want {
+				t.Errorf("checkProfiles() status = %v, want %v (%s)", got.Status, tt.want, got.Detail)
+			}
+			if !strings.Contains(got.Detail, tt.detail) {
+				t.Errorf("checkProfiles() detail = %q, want it to contain %q", got.Detail, tt.detail)
+			}
+			if strings.Count(got.Detail, "重复") > 1 {
+				t.Errorf("checkProfiles() reported a duplicate more than once: %q", got.Detail)
+			}
+		})
+	}
+}
+
+func TestDescribeJSONError(t *testing.T) {
+	unmarshal := func(data string) error {
+		var v interface{}
+		return json.Unmarshal([]byte(data), &v)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		err  error
+		want string
+	}{
+		{"first line", `{"a":}`, unmarshal(`{"a":}`), "第 1 行"},
+		{"later line", "{\n  \"a\": 1,\n  \"b\": ]\n}", unmarshal("{\n  \"a\": 1,\n  \"b\": ]\n}"), "第 3 行"},
+		{"truncated", "{\n  \"a\": 1,\n", unmarshal("{\n  \"a\": 1,\n"), "JSON 无效"},
+		{"not a syntax error", `{}`, errors.New("boom"), "JSON 无效: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeJSONError([]byte(tt.data), tt.err); !strings.Contains(got, tt.want) {
+				t.Errorf("describeJSONError() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckConfigFiles(t *testing.T) {
+	home := sandboxHome(t)
+	configPath, err := config.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claudePath := filepath.Join(home, ".claude", "settings.json")
+
+	if settings, checks := checkConfig(); settings != nil || len(checks) != 1 || checks[0].Status != StatusWarning {
+		t.Errorf("checkConfig() without a file = %v, %+v, want a warning", settings, checks)
+	}
+
+	writeFile(t, configPath, "{\n  \"enabled\": true,\n  \"profiles\": [\n}")
+	if settings, checks := checkConfig(); settings != nil || len(checks) != 1 || !strings.Contains(checks[0].Detail, "第 4 行") {
+		t.Errorf("checkConfig() with invalid JSON = %v, %+v, want an error on line 4", settings, checks)
+	}
+
+	writeFile(t, configPath, `{"enabled":true,"profiles":[]}`)
+	if settings, checks := checkConfig(); settings == nil || !settings.Enabled || checks[0].Problem() {
+		t.Errorf("checkConfig() with a valid file = %v, %+v", settings, checks)
+	}
+
+	writeFile(t, claudePath, `{"env":`)
+	if data, checks := checkClaudeSettings(); data != nil || checks[0].Status != StatusError {
+		t.Errorf("checkClaudeSettings() with invalid JSON = %s, %+v, want an error", data, checks)
+	}
+
+	writeFile(t, claudePath, `{"env":{}}`)
+	if data, checks := checkClaudeSettings(); data == nil || checks[0].Problem() {
+		t.Errorf("checkClaudeSettings() with a valid file = %s, %+v", data, checks)
+	}
+}
@@ -0,0 +1,108 @@
+package events
+
+import "sync"
+
+// Bus delivers published events to every subscriber. Each subscriber
+// receives events in publication order on its own goroutine, so a slow
+// subscriber never blocks the publisher or the others. A nil *Bus discards
+// everything published on it.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+}
+
+// subscriber queues events for a single handler
+type subscriber struct {
+	handle func(Event)
+
+	mu     sync.Mutex
+	queue  []Event
+	wake   chan struct{} // Signalled when the queue becomes non-empty
+	closed chan struct{} // Closed by unsubscribe
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subscribers: map[*subscriber]bool{}}
+}
+
+// Subscribe calls handle for every event published from now on, until the
+// returned function is called. handle runs on a goroutine owned by the
+// subscription; UI code must hand its work to the UI thread itself.
+func (b *Bus) Subscribe(handle func(Event)) (unsubscribe func()) {
+	s := &subscriber{
+		handle: handle,
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = true
+	b.mu.Unlock()
+
+	go s.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+			close(s.closed)
+		})
+	}
+}
+
+// Publish queues event for every current subscriber and returns immediately
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subscribers {
+		s.push(event)
+	}
+}
+
+// push appends an event to the queue and wakes the subscriber
+func (s *subscriber) push(event Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // Already signalled
+	}
+}
+
+// run delivers queued events until the subscriber is closed
+func (s *subscriber) run() {
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-s.wake:
+		}
+
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			event := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+
+			select {
+			case <-s.closed:
+				return
+			default:
+				s.handle(event)
+			}
+		}
+	}
+}
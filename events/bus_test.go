@@ -0,0 +1,114 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records the events a subscriber receives
+type collector struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *collector) handle(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+// wait returns the received events once there are n, or fails after a second
+func (c *collector) wait(t *testing.T, n int) []Event {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		got := append([]Event(nil), c.events...)
+		c.mu.Unlock()
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBusDeliversInOrder(t *testing.T) {
+	bus := NewBus()
+	var first, second collector
+	bus.Subscribe(first.handle)
+	bus.Subscribe(second.handle)
+
+	want := []Event{ProfileAdded{Name: "a"}, ProfileActivated{Name: "a"}, EnabledChanged{Enabled: false}, ProfileDeleted{Name: "a"}}
+	for _, e := range want {
+		bus.Publish(e)
+	}
+
+	for _, c := range []*collector{&first, &second} {
+		if got := c.wait(t, len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("received %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	release := make(chan struct{})
+	defer close(release)
+	bus.Subscribe(func(Event) { <-release })
+	var fast collector
+	bus.Subscribe(fast.handle)
+
+	done := make(chan struct{})
+	go func() {
+		for range 100 {
+			bus.Publish(SettingsChanged{})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+	if got := fast.wait(t, 100); len(got) != 100 {
+		t.Errorf("fast subscriber received %d events, want 100", len(got))
+	}
+}
+
+func TestBusUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	var kept, dropped collector
+	bus.Subscribe(kept.handle)
+	unsubscribe := bus.Subscribe(dropped.handle)
+
+	bus.Publish(ProfileAdded{Name: "a"})
+	dropped.wait(t, 1)
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	bus.Publish(ProfileAdded{Name: "b"})
+
+	kept.wait(t, 2)
+	if got := dropped.wait(t, 1); len(got) != 1 {
+		t.Errorf("unsubscribed handler received %v, want only the first event", got)
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(SettingsChanged{}) // Must not panic
+}
+
+func TestKindsAreDistinct(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range []Event{
+		ProfileActivated{}, ProfileAdded{}, ProfileDeleted{}, ProfileUpdated{},
+		EnabledChanged{}, AutostartChanged{}, SettingsChanged{}, ClaudeSettingsDrifted{},
+	} {
+		if seen[e.Kind()] {
+			t.Errorf("kind %q is used twice", e.Kind())
+		}
+		seen[e.Kind()] = true
+	}
+}
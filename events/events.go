@@ -0,0 +1,78 @@
+// Package events provides a typed publish/subscribe bus for changes to the
+// configuration, so the tray, the control socket and other listeners stay
+// in sync regardless of which of them made a change
+package events
+
+// Event is implemented by every event published on the bus
+type Event interface {
+	// Kind returns a stable identifier for the event type, used when events
+	// are sent outside the process
+	Kind() string
+}
+
+// ProfileActivated is published after a profile became the active one
+type ProfileActivated struct {
+	Name string
+}
+
+// ProfileAdded is published after a profile was created
+type ProfileAdded struct {
+	Name string
+}
+
+// ProfileDeleted is published after a profile was removed
+type ProfileDeleted struct {
+	Name string
+}
+
+// ProfileUpdated is published after a profile was edited. OldName differs
+// from Name when it was renamed.
+type ProfileUpdated struct {
+	OldName string
+	Name    string
+}
+
+// EnabledChanged is published after credentials were written to or removed
+// from Claude Code by enabling or disabling the app
+type EnabledChanged struct {
+	Enabled bool
+}
+
+// AutostartChanged is published after the auto-start entry was created or removed
+type AutostartChanged struct {
+	Enabled bool
+}
+
+// SettingsChanged is published after any other preference changed
+type SettingsChanged struct{}
+
+// ClaudeSettingsDrifted is published when Claude Code's credentials no
+// longer match the active profile
+type ClaudeSettingsDrifted struct {
+	Profile string // The active profile
+	Path    string // Claude Code's settings file
+}
+
+// Kind implements Event
+func (ProfileActivated) Kind() string { return "profileActivated" }
+
+// Kind implements Event
+func (ProfileAdded) Kind() string { return "profileAdded" }
+
+// Kind implements Event
+func (ProfileDeleted) Kind() string { return "profileDeleted" }
+
+// Kind implements Event
+func (ProfileUpdated) Kind() string { return "profileUpdated" }
+
+// Kind implements Event
+func (EnabledChanged) Kind() string { return "enabledChanged" }
+
+// Kind implements Event
+func (AutostartChanged) Kind() string { return "autostartChanged" }
+
+// Kind implements Event
+func (SettingsChanged) Kind() string { return "settingsChanged" }
+
+// Kind implements Event
+func (ClaudeSettingsDrifted) Kind() string { return "claudeSettingsDrifted" }
@@ -0,0 +1,103 @@
+// Package gateway implements the local reverse proxy that forwards Claude
+// Code's requests to the active profile, so switching takes effect on the
+// next request instead of after a restart
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// Resolver returns the gateway token and the active profile, or a nil
+// profile if none is active or the app is disabled. It is called once per
+// request and must be safe to call from any goroutine.
+type Resolver func() (token string, active *models.Profile)
+
+// Handler forwards each request to the profile that is active when it arrives
+type Handler struct {
+	resolve   Resolver
+	report    func(error) // Receives failures to reach a profile
+	transport http.RoundTripper
+}
+
+// NewHandler creates a handler that looks up the active profile with resolve
+func NewHandler(resolve Resolver, report func(error)) *Handler {
+	return &Handler{resolve: resolve, report: report, transport: http.DefaultTransport}
+}
+
+// ServeHTTP checks the gateway token and proxies the request. Responses,
+// including server-sent event streams, are passed through unchanged and
+// flushed as they arrive.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, active := h.resolve()
+	if token == "" || !authorized(r, token) {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "invalid gateway token")
+		return
+	}
+	if active == nil {
+		writeError(w, http.StatusServiceUnavailable, "api_error", "cc-quick-profile is disabled or has no active profile")
+		return
+	}
+
+	target, err := url.Parse(active.APIURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		writeError(w, http.StatusBadGateway, "api_error", fmt.Sprintf("invalid API URL in profile '%s'", active.Name))
+		return
+	}
+	// A profile pointing at the gateway itself would forward forever
+	if strings.EqualFold(target.Host, r.Host) {
+		writeError(w, http.StatusLoopDetected, "api_error", fmt.Sprintf("profile '%s' points at the gateway", active.Name))
+		return
+	}
+
+	profile := *active
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Header.Del("X-Api-Key")
+			pr.Out.Header.Set("Authorization", "Bearer "+profile.APIKey)
+		},
+		Transport:     h.transport,
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if r.Context().Err() != nil {
+				// The client went away, e.g. an interrupted stream
+				return
+			}
+			h.report(fmt.Errorf("failed to forward to profile '%s': %w", profile.Name, err))
+			writeError(w, http.StatusBadGateway, "api_error", fmt.Sprintf("failed to reach profile '%s'", profile.Name))
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// authorized reports whether the request carries the gateway token, either
+// as a bearer token or as an API key
+func authorized(r *http.Request, token string) bool {
+	presented := r.Header.Get("X-Api-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// writeError responds with an error in the shape of the Anthropic API, which
+// Claude Code shows to the user
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"type": "error",
+		"error": map[string]string{
+			"type":    kind,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestHandlerAuth(t *testing.T) {
+	var seen *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Clone(r.Context())
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	profile := &models.Profile{Name: "work", APIURL: upstream.URL + "/api", APIKey: "sk-profile"}
+
+	tests := []struct {
+		name    string
+		token   string
+		active  *models.Profile
+		headers map[string]string
+		want    int
+	}{
+		{"bearer token", "gw-token", profile, map[string]string{"Authorization": "Bearer gw-token"}, http.StatusOK},
+		{"API key header", "gw-token", profile, map[string]string{"X-Api-Key": "gw-token"}, http.StatusOK},
+		{"no credentials", "gw-token", profile, nil, http.StatusUnauthorized},
+		{"wrong token", "gw-token", profile, map[string]string{"Authorization": "Bearer other"}, http.StatusUnauthorized},
+		{"token prefix", "gw-token", profile, map[string]string{"X-Api-Key": "gw-tok"}, http.StatusUnauthorized},
+		{"not a bearer token", "gw-token", profile, map[string]string{"Authorization": "gw-token"}, http.StatusUnauthorized},
+		// The bearer token wins over the API key header
+		{"wrong bearer with right key", "gw-token", profile, map[string]string{"Authorization": "Bearer other", "X-Api-Key": "gw-token"}, http.StatusUnauthorized},
+		// Without a configured token nothing is accepted, not even an empty one
+		{"no gateway token", "", profile, map[string]string{"Authorization": "Bearer "}, http.StatusUnauthorized},
+		{"no active profile", "gw-token", nil, map[string]string{"Authorization": "Bearer gw-token"}, http.StatusServiceUnavailable},
+		{"invalid profile URL", "gw-token", &models.Profile{Name: "bad", APIURL: "ftp://example.com", APIKey: "k"}, map[string]string{"Authorization": "Bearer gw-token"}, http.StatusBadGateway},
+		{"profile at the gateway", "gw-token", &models.Profile{Name: "loop", APIURL: "http://example.com", APIKey: "k"}, map[string]string{"Authorization": "Bearer gw-token"}, http.StatusLoopDetected},
+	}
+	for _, tt := range tests {
+		seen = nil
+		handler := NewHandler(func() (string, *models.Profile) { return tt.token, tt.active }, func(error) {})
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", strings.NewReader("{}"))
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want != http.StatusOK {
+			if seen != nil {
+				t.Errorf("%s: request was forwarded", tt.name)
+			}
+			if !strings.Contains(rec.Body.String(), `"type":"error"`) {
+				t.Errorf("%s: body = %q, want an API error", tt.name, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestHandlerForwards(t *testing.T) {
+	var seen *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Clone(r.Context())
+	}))
+	defer upstream.Close()
+
+	active := &models.Profile{Name: "work", APIURL: upstream.URL + "/api", APIKey: "sk-profile"}
+	handler := NewHandler(func() (string, *models.Profile) { return "gw-token", active }, func(error) {})
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:1/v1/messages?beta=true", nil)
+	req.Header.Set("Authorization", "Bearer gw-token")
+	req.Header.Set("X-Api-Key", "gw-token")
+	req.Header.Set("Anthropic-Version", "2023-06-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen == nil {
+		t.Fatal("request was not forwarded")
+	}
+	if got := seen.URL.RequestURI(); got != "/api/v1/messages?beta=true" {
+		t.Errorf("forwarded to %s, want /api/v1/messages?beta=true", got)
+	}
+	if got := seen.Header.Get("Authorization"); got != "Bearer sk-profile" {
+		t.Errorf("Authorization = %q, want the profile key", got)
+	}
+	if got := seen.Header.Get("X-Api-Key"); got != "" {
+		t.Errorf("X-Api-Key = %q, want it removed", got)
+	}
+	if got := seen.Header.Get("Anthropic-Version"); got != "2023-06-01" {
+		t.Errorf("Anthropic-Version = %q, want it passed through", got)
+	}
+}
+
+func TestHandlerReportsUnreachable(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	address := upstream.URL
+	upstream.Close()
+
+	var reported []error
+	active := &models.Profile{Name: "gone", APIURL: address, APIKey: "k"}
+	handler := NewHandler(func() (string, *models.Profile) { return "t", active }, func(err error) { reported = append(reported, err) })
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:1/v1/models", nil)
+	req.Header.Set("X-Api-Key", "t")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "'gone'") {
+		t.Errorf("reported = %v, want one failure naming the profile", reported)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	settings := &models.Settings{
+		Enabled:  true,
+		Profiles: []models.Profile{{Name: "work", APIURL: "https://a", APIKey: "k1", Active: true}},
+		Gateway:  models.Gateway{Enabled: true, Token: "t1"},
+	}
+	var s Snapshot
+	s.Update(settings)
+
+	token, active := s.Resolve()
+	if token != "t1" || active == nil || active.APIKey != "k1" {
+		t.Fatalf("Resolve() = %q, %v, want t1 and the work profile", token, active)
+	}
+	// The snapshot keeps its own copy
+	settings.Profiles[0].APIKey = "k2"
+	if _, active := s.Resolve(); active.APIKey != "k1" {
+		t.Errorf("snapshot changed with the settings: key %q", active.APIKey)
+	}
+
+	settings.Enabled = false
+	s.Update(settings)
+	if token, active := s.Resolve(); token != "t1" || active != nil {
+		t.Errorf("Resolve() while disabled = %q, %v, want t1 and no profile", token, active)
+	}
+	if !s.Config().Enabled {
+		t.Error("Config().Enabled = false, want true")
+	}
+}
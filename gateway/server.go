@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+const (
+	// retryListen is how long to wait before listening again after the
+	// address was unavailable
+	retryListen = 30 * time.Second
+	// shutdownTimeout bounds how long open streams may finish when the
+	// gateway stops
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server runs the gateway while it is enabled, restarting it when its
+// address changes
+type Server struct {
+	handler http.Handler
+	config  func() models.Gateway // Returns the gateway settings; must be safe to call from any goroutine
+	report  func(error)           // Receives failures to listen or serve, each distinct one once
+	repeat  func(error)           // Receives failures that were already reported, such as on each retry
+	wake    chan struct{}
+}
+
+// NewServer creates a server for handler
+func NewServer(handler http.Handler, config func() models.Gateway, report, repeat func(error)) *Server {
+	return &Server{handler: handler, config: config, report: report, repeat: repeat, wake: make(chan struct{}, 1)}
+}
+
+// Wake makes the server re-read its settings now, such as after they changed
+func (s *Server) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts and stops the gateway as its settings change until ctx is
+// cancelled
+func (s *Server) Run(ctx context.Context) {
+	var (
+		current  *http.Server
+		address  string
+		reported string // The last failure passed to report
+	)
+	fail := func(err error) {
+		if err.Error() == reported {
+			s.repeat(err)
+			return
+		}
+		reported = err.Error()
+		s.report(err)
+	}
+	stop := func() {
+		if current == nil {
+			return
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := current.Shutdown(shutdownCtx); err != nil {
+			current.Close()
+		}
+		current, address = nil, ""
+	}
+	defer stop()
+
+	for {
+		var retry <-chan time.Time
+		config := s.config()
+		want := ""
+		if config.Enabled {
+			want = config.Address()
+		}
+
+		if want != address {
+			stop()
+		}
+		if want == "" {
+			reported = ""
+		}
+		if want != "" && current == nil {
+			if err := config.Validate(); err != nil {
+				// Waits for the settings to be corrected
+				fail(fmt.Errorf("invalid gateway address %s: %w", want, err))
+			} else if server, err := s.listen(want); err != nil {
+				fail(err)
+				retry = time.After(retryListen)
+			} else {
+				current, address, reported = server, want, ""
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-retry:
+		}
+	}
+}
+
+// listen starts serving on address
+func (s *Server) listen(address string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	server := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.report(fmt.Errorf("gateway stopped: %w", err))
+		}
+	}()
+	return server, nil
+}
@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"sync"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// Snapshot holds a copy of the settings the gateway reads, so requests do not
+// wait on the goroutine that owns the settings. The owner calls Update after
+// every change.
+type Snapshot struct {
+	mu     sync.Mutex
+	config models.Gateway
+	active *models.Profile // Nil if none is active or the app is disabled
+}
+
+// Update copies the gateway settings and the profile to forward to
+func (s *Snapshot) Update(settings *models.Settings) {
+	var active *models.Profile
+	if profile := settings.GetActiveProfile(); settings.Enabled && profile != nil {
+		copied := *profile
+		active = &copied
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = settings.Gateway
+	s.active = active
+}
+
+// Resolve is a Resolver reading the snapshot
+func (s *Snapshot) Resolve() (string, *models.Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.Token, s.active
+}
+
+// Config returns the gateway settings in the snapshot
+func (s *Snapshot) Config() models.Gateway {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
@@ -0,0 +1,121 @@
+// Package hooks runs the user's commands around a profile switch
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// Stage identifies when a hook runs
+type Stage string
+
+const (
+	// PreSwitch hooks run before Claude Code's settings are written
+	PreSwitch Stage = "pre-switch"
+	// PostSwitch hooks run after the switch was saved
+	PostSwitch Stage = "post-switch"
+)
+
+// envPrefix starts the names of the variables passed to hooks
+const envPrefix = "CC_QUICK_PROFILE_"
+
+// maxOutput is how much of a failed hook's output is kept for the error
+const maxOutput = 512
+
+// Switch describes the profile switch the hooks run for
+type Switch struct {
+	Old *models.Profile // The previously active profile, nil if none
+	New models.Profile
+}
+
+// Run runs the hooks one after another, stopping at the first failure
+func Run(stage Stage, hooks []models.Hook, sw Switch) error {
+	for _, hook := range hooks {
+		if err := runHook(stage, hook, sw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunAll runs every hook and returns the failures joined together
+func RunAll(stage Stage, hooks []models.Hook, sw Switch) error {
+	errs := []error{}
+	for _, hook := range hooks {
+		if err := runHook(stage, hook, sw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs a single hook with its timeout, returning its output in the
+// error if it fails
+func runHook(stage Stage, hook models.Hook, sw Switch) error {
+	if strings.TrimSpace(hook.Command) == "" {
+		return fmt.Errorf("%s hook has an empty command", stage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hook.Timeout())
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := shellCommand(ctx, hook.Command)
+	cmd.Env = append(os.Environ(), hookEnv(stage, hook, sw)...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = hookWaitDelay
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook %q timed out after %s", stage, hook.Command, hook.Timeout())
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook %q failed: %w%s", stage, hook.Command, err, describeOutput(output.Bytes()))
+	}
+	return nil
+}
+
+// hookEnv returns the variables describing the switch. API keys are only
+// included if the hook asks for them.
+func hookEnv(stage Stage, hook models.Hook, sw Switch) []string {
+	env := []string{
+		envPrefix + "HOOK=" + string(stage),
+		envPrefix + "NEW_PROFILE=" + sw.New.Name,
+		envPrefix + "NEW_API_URL=" + sw.New.APIURL,
+	}
+	if hook.IncludeKey {
+		env = append(env, envPrefix+"NEW_API_KEY="+sw.New.APIKey)
+	}
+
+	if sw.Old != nil {
+		env = append(env,
+			envPrefix+"OLD_PROFILE="+sw.Old.Name,
+			envPrefix+"OLD_API_URL="+sw.Old.APIURL,
+		)
+		if hook.IncludeKey {
+			env = append(env, envPrefix+"OLD_API_KEY="+sw.Old.APIKey)
+		}
+	}
+	return env
+}
+
+// describeOutput formats the end of a failed hook's output for an error
+// message, with secrets removed
+func describeOutput(output []byte) string {
+	text := strings.TrimSpace(string(output))
+	if text == "" {
+		return ""
+	}
+	if len(text) > maxOutput {
+		text = "…" + strings.ToValidUTF8(text[len(text)-maxOutput:], "")
+	}
+	return ": " + redact.String(text)
+}
@@ -0,0 +1,105 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+func TestHookEnv(t *testing.T) {
+	const key = "sk-hook-env-0123456789abcdef"
+	sw := Switch{
+		Old: &models.Profile{Name: "home", APIURL: "https://old.example.com", APIKey: "sk-hook-old-0123456789abcdef"},
+		New: models.Profile{Name: "work", APIURL: "https://api.example.com", APIKey: key},
+	}
+
+	tests := []struct {
+		name       string
+		includeKey bool
+		want       string
+	}{
+		{"key excluded", false, "work https://api.example.com home -"},
+		{"key included", true, "work https://api.example.com home " + key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "env")
+			hook := models.Hook{
+				Command:    `echo "$CC_QUICK_PROFILE_NEW_PROFILE $CC_QUICK_PROFILE_NEW_API_URL $CC_QUICK_PROFILE_OLD_PROFILE ${CC_QUICK_PROFILE_NEW_API_KEY:--}" > ` + out,
+				IncludeKey: tt.includeKey,
+			}
+			// Make sure the key cannot come from the test's own environment
+			t.Setenv(envPrefix+"NEW_API_KEY", "")
+			os.Unsetenv(envPrefix + "NEW_API_KEY")
+
+			if err := Run(PreSwitch, []models.Hook{hook}, sw); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(data)); got != tt.want {
+				t.Errorf("hook saw %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookTimeoutKillsProcessGroup(t *testing.T) {
+	// A background child outlives the shell unless the whole group is killed
+	marker := filepath.Join(t.TempDir(), "marker")
+	hook := models.Hook{Command: "(sleep 2; touch " + marker + ") & sleep 30", TimeoutSeconds: 1}
+
+	start := time.Now()
+	err := Run(PreSwitch, []models.Hook{hook}, Switch{New: models.Profile{Name: "work"}})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > hook.Timeout()+hookWaitDelay+time.Second {
+		t.Errorf("Run() took %v, want it to return soon after the timeout", elapsed)
+	}
+
+	time.Sleep(time.Until(start.Add(3 * time.Second)))
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("a child of the hook kept running after the timeout")
+	}
+}
+
+func TestHookFailureRedactsOutput(t *testing.T) {
+	// The secret only reaches the output, through the environment
+	const secret = "hook-output-secret-value"
+	redact.Register(secret)
+	t.Setenv("HOOK_TEST_SECRET", secret)
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"registered secret", `echo token "$HOOK_TEST_SECRET"; exit 3`, "exit status 3: token " + redact.MaskKey(secret)},
+		{"no output", "exit 4", "exit status 4"},
+		{"long output keeps the end", "printf 'x%.0s' $(seq 600); echo end; exit 1", "…xxx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Run(PostSwitch, []models.Hook{{Command: tt.command}}, Switch{New: models.Profile{Name: "work"}})
+			if err == nil {
+				t.Fatal("Run() error = nil, want the failure")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() error = %v, want it to contain %q", err, tt.want)
+			}
+			if strings.Contains(err.Error(), secret) {
+				t.Errorf("Run() error = %v, want the secret masked", err)
+			}
+		})
+	}
+}
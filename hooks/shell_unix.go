@@ -0,0 +1,24 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// hookWaitDelay is how long to wait for output after a hook was killed
+const hookWaitDelay = time.Second
+
+// shellCommand runs line with sh in its own process group, so a timeout
+// kills everything the hook started
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", line)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// hookWaitDelay is how long to wait for output after a hook was killed
+const hookWaitDelay = time.Second
+
+// shellCommand runs line with cmd.exe
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", line)
+}
@@ -0,0 +1,223 @@
+// Package instance ensures that only one instance, the tray or the daemon,
+// runs per user, using a Unix socket in the runtime directory as the lock
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRunning is returned by Acquire and Lock when another instance holds
+	// the lock. Acquire has handed its arguments to that instance by then.
+	ErrRunning = errors.New("another instance is running")
+	// ErrIgnored is returned by Acquire when the running instance, such as
+	// the headless daemon, did not act on the arguments. It wraps ErrRunning.
+	ErrIgnored = fmt.Errorf("%w and ignored the launch", ErrRunning)
+)
+
+// handoffTimeout bounds a single exchange on the socket
+const handoffTimeout = 5 * time.Second
+
+// Replies of the running instance to a handoff
+const (
+	replyHandled = "ok"
+	replyIgnored = "ignored"
+)
+
+// handoff is the message a second launch sends to the running instance
+type handoff struct {
+	Args []string `json:"args"`
+}
+
+// Instance holds the single-instance lock
+type Instance struct {
+	listener net.Listener
+	pidPath  string // Set once the PID file was written
+}
+
+// RuntimeDir returns the per-user directory for sockets, creating it with
+// owner-only permissions
+func RuntimeDir() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		dir = filepath.Join(dir, "cc-quick-profile")
+	} else {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("cc-quick-profile-%d", os.Getuid()))
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create runtime directory: %w", err)
+	}
+	// Fails if another user created the directory first
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to secure runtime directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// Acquire takes the single-instance lock. If another instance holds it, args
+// are handed to that instance and ErrRunning is returned, or ErrIgnored if
+// that instance did not act on them. A socket left behind by a crashed
+// instance is removed and the lock taken over.
+func Acquire(args []string) (*Instance, error) {
+	return acquire(func(conn net.Conn) error {
+		reply, err := send(conn, args)
+		if err != nil {
+			return fmt.Errorf("failed to hand over to running instance: %w", err)
+		}
+		if reply == replyIgnored {
+			return ErrIgnored
+		}
+		return ErrRunning
+	})
+}
+
+// Lock takes the single-instance lock like Acquire, but returns ErrRunning
+// without handing anything to an instance that holds it
+func Lock() (*Instance, error) {
+	return acquire(func(conn net.Conn) error {
+		conn.Close()
+		return ErrRunning
+	})
+}
+
+// acquire takes the lock, or calls running with a connection to the
+// instance holding it and returns its error
+func acquire(running func(conn net.Conn) error) (*Instance, error) {
+	dir, err := RuntimeDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "instance.sock")
+
+	// Without the lock, two launches could both find the socket stale and
+	// one remove the socket the other has just started listening on
+	unlock, err := lockTakeover(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		listener, listenErr := net.Listen("unix", path)
+		if listenErr == nil {
+			return &Instance{listener: listener}, nil
+		}
+		err = listenErr
+
+		// Only a socket nobody accepts on is stale
+		conn, dialErr := net.DialTimeout("unix", path, handoffTimeout)
+		if dialErr == nil {
+			return nil, running(conn)
+		}
+		if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", removeErr)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+}
+
+// lockTakeover serializes acquire between processes with a lock on a file
+// next to the socket, returning a function that releases it
+func lockTakeover(dir string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(dir, "instance.lock"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// send hands args to the running instance, closes conn and returns the reply
+func send(conn net.Conn, args []string) (string, error) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoffTimeout))
+
+	if err := json.NewEncoder(conn).Encode(handoff{Args: args}); err != nil {
+		return "", err
+	}
+
+	// Wait for the acknowledgement so the caller only exits once handled
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSpace(reply), err
+}
+
+// Serve calls handler with the arguments of every later launch. handler
+// reports whether it acted on them, which the launch is told. Serve returns
+// immediately; handler runs on a background goroutine.
+func (i *Instance) Serve(handler func(args []string) bool) {
+	go func() {
+		for {
+			conn, err := i.listener.Accept()
+			if err != nil {
+				return
+			}
+			go i.handle(conn, handler)
+		}
+	}()
+}
+
+// handle reads one handoff message and acknowledges it
+func (i *Instance) handle(conn net.Conn, handler func(args []string) bool) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoffTimeout))
+
+	var msg handoff
+	if err := json.NewDecoder(conn).Decode(&msg); err != nil {
+		return
+	}
+
+	reply := replyHandled
+	if !handler(msg.Args) {
+		reply = replyIgnored
+	}
+	fmt.Fprintln(conn, reply)
+}
+
+// PIDPath returns the path of the file holding the running instance's PID
+func PIDPath() (string, error) {
+	dir, err := RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "instance.pid"), nil
+}
+
+// WritePID records the process ID at PIDPath so scripts can signal this
+// instance. The file is removed by Close.
+func (i *Instance) WritePID() error {
+	path, err := PIDPath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	i.pidPath = path
+	return nil
+}
+
+// Close releases the lock, removing the socket and the PID file
+func (i *Instance) Close() error {
+	if i.pidPath != "" {
+		os.Remove(i.pidPath)
+	}
+	return i.listener.Close()
+}
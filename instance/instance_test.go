@@ -0,0 +1,101 @@
+package instance
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestAcquireHandoff(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	tests := []struct {
+		name    string
+		handled bool
+		want    error
+	}{
+		{"tray", true, ErrRunning},
+		{"daemon", false, ErrIgnored},
+	}
+	for _, tt := range tests {
+		lock, err := Acquire(nil)
+		if err != nil {
+			t.Fatalf("%s: Acquire() error = %v", tt.name, err)
+		}
+		received := make(chan []string, 1)
+		lock.Serve(func(args []string) bool {
+			received <- args
+			return tt.handled
+		})
+
+		_, err = Acquire([]string{"ccqp://activate?profile=work"})
+		if !errors.Is(err, tt.want) || !errors.Is(err, ErrRunning) {
+			t.Errorf("%s: second Acquire() error = %v, want %v", tt.name, err, tt.want)
+		}
+		if tt.handled && errors.Is(err, ErrIgnored) {
+			t.Errorf("%s: second Acquire() reported the launch as ignored", tt.name)
+		}
+		if args := <-received; !slices.Equal(args, []string{"ccqp://activate?profile=work"}) {
+			t.Errorf("%s: handler got %v", tt.name, args)
+		}
+
+		if _, err := Lock(); !errors.Is(err, ErrRunning) || errors.Is(err, ErrIgnored) {
+			t.Errorf("%s: Lock() error = %v, want ErrRunning", tt.name, err)
+		}
+		lock.Close()
+	}
+}
+
+func TestLockTakesOverStaleSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	dir, err := RuntimeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crashed instance leaves its socket behind
+	stale, err := net.Listen("unix", filepath.Join(dir, "instance.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	// Of several launches racing for the stale socket, exactly one wins
+	const launches = 8
+	var wg sync.WaitGroup
+	locks := make(chan *Instance, launches)
+	errs := make(chan error, launches)
+	for i := 0; i < launches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := Lock()
+			if err != nil {
+				errs <- err
+				return
+			}
+			locks <- lock
+		}()
+	}
+	wg.Wait()
+	close(locks)
+	close(errs)
+
+	for err := range errs {
+		if !errors.Is(err, ErrRunning) {
+			t.Errorf("Lock() error = %v, want ErrRunning", err)
+		}
+	}
+	won := 0
+	for lock := range locks {
+		won++
+		defer lock.Close()
+	}
+	if won != 1 {
+		t.Errorf("%d launches took the lock, want 1", won)
+	}
+}
@@ -1,71 +1,293 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/driver/desktop"
 	"github.com/ipfans/cc-quick-profile/assets"
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/cli"
 	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/control"
+	"github.com/ipfans/cc-quick-profile/deeplink"
+	"github.com/ipfans/cc-quick-profile/events"
+	"github.com/ipfans/cc-quick-profile/gateway"
+	"github.com/ipfans/cc-quick-profile/instance"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"github.com/ipfans/cc-quick-profile/sessions"
+	"github.com/ipfans/cc-quick-profile/shellenv"
+	"github.com/ipfans/cc-quick-profile/terminal"
 	"github.com/ipfans/cc-quick-profile/ui"
+	"github.com/ipfans/cc-quick-profile/webhook"
 )
 
 var (
-	configManager  *config.Manager
-	fyneApp        fyne.App
-	mainWindow     fyne.Window
-	systemTrayMenu *fyne.Menu
+	configManager    *config.Manager
+	eventBus         *events.Bus
+	fyneApp          fyne.App
+	mainWindow       fyne.Window
+	managementWindow *ui.ManagementWindow
+	sessionTracker   *sessions.Tracker
+	systemTrayMenu   *fyne.Menu
+
+	// recentProjects is listed in the launch submenu. It is only touched on
+	// the UI thread and refreshed in the background by refreshRecentProjects.
+	recentProjects []claude.Project
 )
 
+// clipboardClearChoices are the auto-clear delays offered in the tray menu
+var clipboardClearChoices = []int{10, 30, 60, 120}
+
+// recentProjectLimit is how many projects the launch submenu lists
+const recentProjectLimit = 10
+
+// notificationLabels name the notification categories in the tray menu
+var notificationLabels = map[string]string{
+	models.NotifySwitch: "切换配置成功",
+	models.NotifyError:  "操作失败",
+}
+
 func main() {
+	// Mask secrets in everything written to the log
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
+	// Run headless subcommands without starting the GUI
+	if len(os.Args) > 1 && !isLaunchArgument(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
+	// A non-zero exit code is set instead of exiting at once, so the deferred
+	// cleanup removes the PID file and the control socket first
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Catch control signals before the PID file tells scripts where to send them
+	signals := catchSignals()
+
+	// Hand over to an instance that is already running
+	lock, err := instance.Acquire(os.Args[1:])
+	if errors.Is(err, instance.ErrIgnored) {
+		log.Println("无界面守护进程正在运行，无法打开管理窗口；请使用命令行管理配置")
+		exitCode = 1
+		return
+	}
+	if errors.Is(err, instance.ErrRunning) {
+		log.Println("程序已在运行，已通知其打开管理窗口")
+		return
+	}
+	if err != nil {
+		log.Printf("获取单实例锁失败，继续启动: %v", err)
+	} else {
+		defer lock.Close()
+		if err := lock.WritePID(); err != nil {
+			log.Printf("写入 PID 文件失败: %v", err)
+		}
+	}
+
 	// Initialize Fyne app
 	fyneApp = app.New()
 
 	// Initialize config manager
-	var err error
 	configManager, err = config.NewManager()
 	if err != nil {
-		log.Fatalf("初始化配置管理器失败: %v", err)
+		log.Printf("初始化配置管理器失败: %v", err)
+		exitCode = 1
+		return
 	}
 
+	// Every change made through the manager is announced on the bus
+	eventBus = events.NewBus()
+	configManager.SetEventBus(eventBus)
+	configManager.SetErrorReporter(func(err error) {
+		log.Printf("配置已保存，但后续处理失败: %v", err)
+	})
+
 	// Create a hidden main window (required for app lifecycle)
 	mainWindow = fyneApp.NewWindow("CC Quick Profile")
 	mainWindow.SetCloseIntercept(func() {
 		mainWindow.Hide()
 	})
 
+	// The main window doubles as the profile management window
+	managementWindow = ui.NewManagementWindow(mainWindow, configManager, showAddProfileModal)
+
+	// Later launches open the management window of this instance
+	if lock != nil {
+		lock.Serve(func(args []string) bool {
+			fyne.Do(func() {
+				log.Printf("收到新实例的启动请求: %v", args)
+				handleLaunch(args)
+			})
+			return true
+		})
+	}
+
+	// Let ccqp:// links open this executable
+	if executable, err := os.Executable(); err == nil {
+		if err := deeplink.Register(executable); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			log.Printf("注册 %s:// 链接处理程序失败: %v", deeplink.Scheme, err)
+		}
+	}
+
 	// Set up system tray if supported
 	if desk, ok := fyneApp.(desktop.App); ok {
 		// Set system tray icon
 		desk.SetSystemTrayIcon(fyne.NewStaticResource("icon", assets.Icon))
 
-		// Build and set system tray menu
+		// Sessions still using the old profile are listed in the menu
+		// until they exit
+		sessionTracker = sessions.NewTracker(func() {
+			fyne.Do(func() {
+				updateSystemTrayMenu(desk)
+			})
+			refreshRecentProjects(desk)
+		})
+
+		// Build and set system tray menu, then fill in the recent projects
 		updateSystemTrayMenu(desk)
+		refreshRecentProjects(desk)
+
+		// Rebuild the menu after every change, on the UI thread since
+		// events are delivered on the bus's own goroutine
+		unsubscribe := eventBus.Subscribe(func(e events.Event) {
+			log.Printf("配置已更改: %s", e.Kind())
+			fyne.Do(func() {
+				if activated, ok := e.(events.ProfileActivated); ok {
+					announceSwitch(activated.Name)
+				}
+				updateSystemTrayMenu(desk)
+			})
+			refreshRecentProjects(desk)
+		})
+		defer unsubscribe()
 
 		log.Println("系统托盘已初始化")
 	} else {
 		log.Println("此平台不支持系统托盘")
 	}
 
+	// Serve the control socket; requests run on the UI thread so they never
+	// race with the menu
+	controlServer, err := control.Listen(eventBus, withManager)
+	if err != nil {
+		log.Printf("启动控制套接字失败: %v", err)
+	} else {
+		defer controlServer.Close()
+	}
+
+	// Window manager keybindings can send signals to switch profiles
+	stopSignals := watchSignals(signals)
+	defer stopSignals()
+
+	// Deliver queued webhooks in the background, checking after each change
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	webhookSender := webhook.NewSender(configManager.WebhookQueue(), func() []models.Webhook {
+		var webhooks []models.Webhook
+		fyne.DoAndWait(func() {
+			webhooks = slices.Clone(configManager.GetSettings().Webhooks)
+		})
+		return webhooks
+	}, func(err error) {
+		log.Printf("Webhook 投递失败: %v", err)
+	})
+	unsubscribeWebhooks := eventBus.Subscribe(func(events.Event) {
+		webhookSender.Wake()
+	})
+	defer unsubscribeWebhooks()
+	go webhookSender.Run(ctx)
+
+	// Serve the local gateway while it is enabled. Requests read a snapshot
+	// of the settings, refreshed on the UI thread after every change, so
+	// proxied traffic never waits for the UI.
+	gatewaySnapshot := &gateway.Snapshot{}
+	gatewaySnapshot.Update(configManager.GetSettings())
+	gatewayHandler := gateway.NewHandler(gatewaySnapshot.Resolve, func(err error) {
+		log.Printf("网关转发失败: %v", err)
+	})
+	gatewayServer := gateway.NewServer(gatewayHandler, gatewaySnapshot.Config, func(err error) {
+		fyne.Do(func() {
+			reportError("本地网关出错", err)
+		})
+	}, func(err error) {
+		log.Printf("本地网关仍然出错: %v", err)
+	})
+	unsubscribeGateway := eventBus.Subscribe(func(events.Event) {
+		fyne.DoAndWait(func() {
+			gatewaySnapshot.Update(configManager.GetSettings())
+		})
+		gatewayServer.Wake()
+	})
+	defer unsubscribeGateway()
+	go gatewayServer.Run(ctx)
+
+	// Open the links this launch was given once the app is running
+	if len(os.Args) > 1 {
+		fyneApp.Lifecycle().SetOnStarted(func() {
+			handleLaunch(os.Args[1:])
+		})
+	}
+
 	// Run the app
 	mainWindow.ShowAndRun()
 }
 
+// refreshRecentProjects scans Claude Code's session history in the
+// background and rebuilds the menu on the UI thread if the projects changed
+func refreshRecentProjects(desk desktop.App) {
+	go func() {
+		projects, err := claude.RecentProjects(recentProjectLimit)
+		if err != nil {
+			log.Printf("读取 Claude Code 项目失败: %v", err)
+			return
+		}
+		fyne.Do(func() {
+			sameDir := func(a, b claude.Project) bool { return a.Dir == b.Dir }
+			if slices.EqualFunc(projects, recentProjects, sameDir) {
+				return
+			}
+			recentProjects = projects
+			updateSystemTrayMenu(desk)
+		})
+	}()
+}
+
 func updateSystemTrayMenu(desk desktop.App) {
 	settings := configManager.GetSettings()
 
 	menuItems := []*fyne.MenuItem{}
 
+	// Claude Code sessions started before the last switch
+	if stale := sessionTracker.Stale(); len(stale) > 0 {
+		sessionItems := []*fyne.MenuItem{}
+		for _, session := range stale {
+			item := fyne.NewMenuItem(sessionLabel(session), func() {})
+			item.Disabled = true
+			sessionItems = append(sessionItems, item)
+		}
+		staleItem := fyne.NewMenuItem(fmt.Sprintf("⚠ %d 个会话仍在使用旧配置", len(stale)), nil)
+		staleItem.ChildMenu = fyne.NewMenu("", sessionItems...)
+		menuItems = append(menuItems, staleItem, fyne.NewMenuItemSeparator())
+	}
+
 	// Enable/Disable toggle
 	enabledItem := fyne.NewMenuItem("已禁用", func() {
-		settings.Enabled = !settings.Enabled
-		if err := configManager.SetEnabled(settings.Enabled); err != nil {
-			log.Printf("更新启用状态失败: %v", err)
-		} else {
-			log.Printf("启用状态已更改为: %v", settings.Enabled)
-			updateSystemTrayMenu(desk)
-		}
+		toggleEnabled()
 	})
 	if settings.Enabled {
 		enabledItem.Label = "✓ 已启用"
@@ -76,10 +298,9 @@ func updateSystemTrayMenu(desk desktop.App) {
 	autostartItem := fyne.NewMenuItem("开机不启动", func() {
 		newAutoStart := !settings.AutoStart
 		if err := configManager.SetAutoStart(newAutoStart); err != nil {
-			log.Printf("更新自启动状态失败: %v", err)
+			reportError("更新自启动状态失败", err)
 		} else {
 			log.Printf("自启动状态已更改为: %v", newAutoStart)
-			updateSystemTrayMenu(desk)
 		}
 	})
 	if settings.AutoStart {
@@ -87,6 +308,13 @@ func updateSystemTrayMenu(desk desktop.App) {
 	}
 	menuItems = append(menuItems, autostartItem)
 
+	// Local gateway toggle
+	gatewayItem := fyne.NewMenuItem("本地网关 ("+settings.Gateway.Address()+")", func() {
+		toggleGateway()
+	})
+	gatewayItem.Checked = settings.Gateway.Enabled
+	menuItems = append(menuItems, gatewayItem)
+
 	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
 
 	// Profile list
@@ -102,16 +330,7 @@ func updateSystemTrayMenu(desk desktop.App) {
 				menuText = "✓ " + menuText
 			}
 			profileItem := fyne.NewMenuItem(menuText, func() {
-				if err := configManager.SetActiveProfile(p.Name); err != nil {
-					log.Printf("设置活动配置失败: %v", err)
-				} else {
-					log.Printf("已切换到配置: %s", p.Name)
-					// Reload settings and update menu
-					if err := configManager.Load(); err != nil {
-						log.Printf("重新加载配置失败: %v", err)
-					}
-					updateSystemTrayMenu(desk)
-				}
+				requestActivation(p.Name)
 			})
 			menuItems = append(menuItems, profileItem)
 		}
@@ -119,9 +338,123 @@ func updateSystemTrayMenu(desk desktop.App) {
 
 	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
 
+	// Launch Claude Code in a recent project
+	projectItems := []*fyne.MenuItem{}
+	for _, project := range recentProjects {
+		dir := project.Dir // capture for closure
+		projectItems = append(projectItems, fyne.NewMenuItem(displayPath(dir), func() {
+			openClaude(dir)
+		}))
+	}
+	if len(projectItems) == 0 {
+		noProjectsItem := fyne.NewMenuItem("暂无最近项目", func() {})
+		noProjectsItem.Disabled = true
+		projectItems = append(projectItems, noProjectsItem)
+	}
+	openItem := fyne.NewMenuItem("在…中打开 Claude Code", nil)
+	openItem.ChildMenu = fyne.NewMenu("", projectItems...)
+	menuItems = append(menuItems, openItem)
+
+	// Copy secrets to clipboard
+	if len(settings.Profiles) > 0 {
+		copyKeyItems := []*fyne.MenuItem{}
+		copyExportItems := []*fyne.MenuItem{}
+		for _, profile := range settings.Profiles {
+			p := profile // capture for closure
+			keyLabel := fmt.Sprintf("%s (%s)", p.Name, redact.MaskKey(p.APIKey))
+			copyKeyItems = append(copyKeyItems, fyne.NewMenuItem(keyLabel, func() {
+				copySecret(fmt.Sprintf("配置 %s 的 API 密钥", p.Name), p.APIKey)
+			}))
+			copyExportItems = append(copyExportItems, fyne.NewMenuItem(p.Name, func() {
+				copySecret(fmt.Sprintf("配置 %s 的环境变量", p.Name), shellenv.Render(shellenv.Posix, shellenv.Vars(p)))
+			}))
+		}
+
+		copyKeyItem := fyne.NewMenuItem("复制 API 密钥", nil)
+		copyKeyItem.ChildMenu = fyne.NewMenu("", copyKeyItems...)
+		menuItems = append(menuItems, copyKeyItem)
+
+		copyExportItem := fyne.NewMenuItem("复制环境变量", nil)
+		copyExportItem.ChildMenu = fyne.NewMenu("", copyExportItems...)
+		menuItems = append(menuItems, copyExportItem)
+	}
+
+	// Per-profile switch confirmation
+	if len(settings.Profiles) > 0 {
+		confirmItems := []*fyne.MenuItem{}
+		for _, profile := range settings.Profiles {
+			p := profile // capture for closure
+			item := fyne.NewMenuItem(p.Name, func() {
+				if err := configManager.SetConfirmSwitch(p.Name, !p.ConfirmSwitch); err != nil {
+					log.Printf("更新切换确认设置失败: %v", err)
+				}
+			})
+			item.Checked = p.ConfirmSwitch
+			confirmItems = append(confirmItems, item)
+		}
+
+		confirmItem := fyne.NewMenuItem("切换前预览更改", nil)
+		confirmItem.ChildMenu = fyne.NewMenu("", confirmItems...)
+		menuItems = append(menuItems, confirmItem)
+	}
+
+	// Notification categories
+	notificationItems := []*fyne.MenuItem{}
+	for _, category := range models.NotificationCategories {
+		c := category // capture for closure
+		enabled := settings.NotificationEnabled(c)
+		item := fyne.NewMenuItem(notificationLabels[c], func() {
+			if err := configManager.SetNotificationEnabled(c, !enabled); err != nil {
+				log.Printf("更新通知设置失败: %v", err)
+			}
+		})
+		item.Checked = enabled
+		notificationItems = append(notificationItems, item)
+	}
+	notificationItem := fyne.NewMenuItem("通知", nil)
+	notificationItem.ChildMenu = fyne.NewMenu("", notificationItems...)
+	menuItems = append(menuItems, notificationItem)
+
+	// Clipboard auto-clear delay
+	clearItems := []*fyne.MenuItem{}
+	for _, seconds := range clipboardClearChoices {
+		s := seconds // capture for closure
+		item := fyne.NewMenuItem(fmt.Sprintf("%d 秒", s), func() {
+			if err := configManager.SetClipboardClearSeconds(s); err != nil {
+				log.Printf("更新剪贴板清除时间失败: %v", err)
+			} else {
+				log.Printf("剪贴板清除时间已更改为: %d 秒", s)
+			}
+		})
+		item.Checked = time.Duration(s)*time.Second == settings.ClipboardClearDelay()
+		clearItems = append(clearItems, item)
+	}
+	clearItem := fyne.NewMenuItem(fmt.Sprintf("剪贴板自动清除: %d 秒", int(settings.ClipboardClearDelay().Seconds())), nil)
+	clearItem.ChildMenu = fyne.NewMenu("", clearItems...)
+	menuItems = append(menuItems, clearItem)
+
+	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
+
 	// Add new profile
 	menuItems = append(menuItems, fyne.NewMenuItem("添加新配置", func() {
-		showAddProfileModal(desk)
+		showAddProfileModal()
+	}))
+
+	// Profile management window
+	menuItems = append(menuItems, fyne.NewMenuItem("管理配置", func() {
+		managementWindow.Show()
+	}))
+
+	// Audit log viewer
+	menuItems = append(menuItems, fyne.NewMenuItem("审计日志", func() {
+		ui.ShowAuditWindow(fyneApp, configManager)
+	}))
+
+	// Emergency credential wipe
+	menuItems = append(menuItems, fyne.NewMenuItem("紧急清除所有凭据", func() {
+		ui.ShowWipeWindow(fyneApp, withManager, func() {
+			log.Println("已执行紧急凭据清除")
+		})
 	}))
 
 	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
@@ -134,25 +467,220 @@ func updateSystemTrayMenu(desk desktop.App) {
 	// Create and set the menu
 	systemTrayMenu = fyne.NewMenu("CC Quick Profile", menuItems...)
 	desk.SetSystemTrayMenu(systemTrayMenu)
+
+	if managementWindow != nil {
+		managementWindow.Refresh()
+	}
+}
+
+// isLaunchArgument reports whether arg is handed to the GUI, such as a URL,
+// rather than naming a subcommand
+func isLaunchArgument(arg string) bool {
+	return strings.Contains(arg, "://")
+}
+
+// handleLaunch opens the ccqp:// links a launch was given, or the management
+// window if there were none
+func handleLaunch(args []string) {
+	opened := false
+	for _, arg := range args {
+		if !isLaunchArgument(arg) {
+			continue
+		}
+		opened = true
+
+		link, err := deeplink.Parse(arg)
+		if err != nil {
+			reportError("无法打开链接", err)
+			continue
+		}
+		switch link := link.(type) {
+		case deeplink.Activate:
+			requestActivation(link.Profile)
+		case deeplink.Import:
+			ui.ShowImportDialog(fyneApp, configManager, link.Profiles)
+		}
+	}
+
+	if !opened {
+		managementWindow.Show()
+	}
 }
 
-func showAddProfileModal(desk desktop.App) {
-	// Create event channel for modal communication
-	uiEventChan := make(chan ui.Event, 1)
+// requestActivation switches to the named profile, first showing a preview
+// if the profile asks for one
+func requestActivation(name string) {
+	profile := configManager.GetSettings().FindProfile(name)
+	if profile == nil {
+		reportError("设置活动配置失败", fmt.Errorf("%w: '%s'", config.ErrProfileNotFound, name))
+		return
+	}
+
+	if profile.ConfirmSwitch {
+		ui.ShowSwitchPreview(fyneApp, configManager, name, func() {
+			activateProfile(name)
+		})
+		return
+	}
+	activateProfile(name)
+}
 
-	// Handle events from modal
+// activateProfile switches to the named profile. The switch runs in the
+// background so its hooks never block the UI thread; the menu is rebuilt
+// when the manager announces the change.
+func activateProfile(name string) {
 	go func() {
-		for event := range uiEventChan {
-			if event.Type == ui.EventConfigUpdated {
-				// Reload config and update menu
-				if err := configManager.Load(); err != nil {
-					log.Printf("重新加载配置失败: %v", err)
-				}
-				updateSystemTrayMenu(desk)
+		err := config.SwitchProfile(withManager, name)
+		fyne.Do(func() {
+			if err != nil && !errors.Is(err, config.ErrPostSwitchHook) {
+				reportError("设置活动配置失败", err)
+				return
 			}
-		}
+
+			log.Printf("已切换到配置: %s", name)
+			if err != nil {
+				reportError("切换后钩子执行失败", err)
+			}
+		})
 	}()
+}
+
+// withManager runs fn on the UI thread, which is the only place the config
+// manager is used. It must not be called from the UI thread itself.
+func withManager(fn func(m *config.Manager) error) error {
+	var err error
+	fyne.DoAndWait(func() {
+		err = fn(configManager)
+	})
+	return err
+}
+
+// cycleProfile switches to the profile after the active one
+func cycleProfile() {
+	next := configManager.GetSettings().NextProfile()
+	if next == nil {
+		log.Println("没有可切换的配置")
+		return
+	}
+	requestActivation(next.Name)
+}
+
+// toggleEnabled turns writing credentials to Claude Code on or off
+func toggleEnabled() {
+	enabled := !configManager.GetSettings().Enabled
+	if err := configManager.SetEnabled(enabled); err != nil {
+		reportError("更新启用状态失败", err)
+		return
+	}
+	log.Printf("启用状态已更改为: %v", enabled)
+}
+
+// toggleGateway turns the local gateway on or off
+func toggleGateway() {
+	enabled := !configManager.GetSettings().Gateway.Enabled
+	if err := configManager.SetGatewayEnabled(enabled); err != nil {
+		reportError("更新本地网关失败", err)
+		return
+	}
+	log.Printf("本地网关状态已更改为: %v", enabled)
+}
+
+// reloadSettings re-reads the settings file, keeping the current settings
+// if it cannot be parsed
+func reloadSettings() {
+	if err := configManager.Reload(); err != nil {
+		reportError("重新加载配置失败", err)
+		return
+	}
+	log.Println("配置已重新加载")
+}
+
+// announceSwitch tells the user a profile was activated and that Claude
+// Code has to be restarted to use it, counting the sessions that still run
+// with the old profile
+func announceSwitch(name string) {
+	settings := configManager.GetSettings()
+	if !settings.Enabled {
+		notify(models.NotifySwitch, "已切换到配置: "+name, "当前处于禁用状态，Claude Code 设置未更新")
+		return
+	}
+	if settings.Gateway.Enabled {
+		notify(models.NotifySwitch, "已切换到配置: "+name, "Claude Code 的下一个请求将通过本地网关使用新配置")
+		return
+	}
+
+	content := "请重启 Claude Code 以使用新配置"
+	if stale := sessionTracker.Switched(time.Now()); len(stale) > 0 {
+		content = fmt.Sprintf("%d 个 Claude Code 会话仍在使用旧配置，请重启以使用新配置", len(stale))
+	}
+	notify(models.NotifySwitch, "已切换到配置: "+name, content)
+}
+
+// sessionLabel describes a session by its PID and working directory
+func sessionLabel(session sessions.Session) string {
+	dir := "未知目录"
+	if session.Dir != "" {
+		dir = displayPath(session.Dir)
+	}
+	return fmt.Sprintf("PID %d: %s", session.PID, dir)
+}
+
+// displayPath shortens a path inside the home directory to start with ~
+func displayPath(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	rel, err := filepath.Rel(home, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dir
+	}
+	return filepath.Join("~", rel)
+}
+
+// openClaude starts Claude Code in dir in a new terminal window, using the
+// active profile if there is one
+func openClaude(dir string) {
+	settings := configManager.GetSettings()
+	command, err := terminal.Command(settings.TerminalCommand)
+	if err != nil {
+		reportError("打开 Claude Code 失败", fmt.Errorf("%w (请在设置文件中配置 terminalCommand)", err))
+		return
+	}
+
+	profile := ""
+	if active := settings.GetActiveProfile(); active != nil {
+		profile = active.Name
+	}
+	if err := terminal.OpenClaude(command, dir, profile); err != nil {
+		reportError("打开 Claude Code 失败", err)
+		return
+	}
+	log.Printf("已在 %s 中打开 Claude Code", dir)
+}
+
+// reportError logs a failed action and shows it as a notification
+func reportError(action string, err error) {
+	log.Printf("%s: %v", action, err)
+	notify(models.NotifyError, action, redact.Error(err).Error())
+}
+
+// notify shows a desktop notification unless its category is turned off
+func notify(category, title, content string) {
+	if !configManager.GetSettings().NotificationEnabled(category) {
+		return
+	}
+	fyneApp.SendNotification(fyne.NewNotification(title, content))
+}
+
+// copySecret places a secret on the clipboard and schedules it to be cleared
+func copySecret(description, secret string) {
+	delay := configManager.GetSettings().ClipboardClearDelay()
+	ui.CopySecret(fyneApp, secret, delay)
+	log.Printf("已复制%s，%d 秒后自动清除", description, int(delay.Seconds()))
+}
 
-	// Show the modal
-	ui.ShowAddProfileModal(fyneApp, configManager, uiEventChan)
+// showAddProfileModal opens the dialog for creating a profile
+func showAddProfileModal() {
+	ui.ShowAddProfileModal(fyneApp, configManager)
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// DefaultGatewayListen is the address the gateway listens on unless configured
+const DefaultGatewayListen = "127.0.0.1:8787"
+
+// Gateway configures the local reverse proxy that forwards Claude Code's
+// requests to the active profile
+type Gateway struct {
+	Enabled bool   `json:"enabled"`
+	Listen  string `json:"listen,omitempty"` // Loopback host:port; DefaultGatewayListen if empty
+	Token   string `json:"token,omitempty"`  // Written to Claude Code instead of a profile key; requests must present it
+}
+
+// Address returns the host:port the gateway listens on
+func (g Gateway) Address() string {
+	if g.Listen == "" {
+		return DefaultGatewayListen
+	}
+	return g.Listen
+}
+
+// URL returns the base URL Claude Code is pointed at
+func (g Gateway) URL() string {
+	return "http://" + g.Address()
+}
+
+// Validate checks that the gateway only listens on the loopback interface,
+// as it adds the active profile's key to every request it forwards
+func (g Gateway) Validate() error {
+	host, port, err := net.SplitHostPort(g.Address())
+	if err != nil {
+		return errors.New("网关监听地址必须是 host:port 格式")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return errors.New("网关端口无效")
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return errors.New("网关只能监听本机回环地址")
+	}
+	return nil
+}
+
+// ClaudeCredentials returns the token and base URL written to Claude Code's
+// settings for a profile. While the gateway is enabled they point at the
+// gateway instead and stay the same across switches.
+func (s *Settings) ClaudeCredentials(p Profile) (apiKey, apiURL string) {
+	if s.Gateway.Enabled {
+		return s.Gateway.Token, s.Gateway.URL()
+	}
+	return p.APIKey, p.APIURL
+}
+
+// ClaudeInSync reports whether the credentials found in Claude Code's
+// settings are the ones the enabled state and active profile call for
+func (s *Settings) ClaudeInSync(apiKey, apiURL string) bool {
+	active := s.GetActiveProfile()
+	if active == nil {
+		return !s.Enabled || apiKey == "" || apiURL == ""
+	}
+	wantKey, wantURL := s.ClaudeCredentials(*active)
+	return s.Enabled && apiKey == wantKey && apiURL == wantURL
+}
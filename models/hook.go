@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// DefaultHookTimeoutSeconds is how long a hook may run unless it sets its own limit
+const DefaultHookTimeoutSeconds = 10
+
+// Hook is a shell command run around a profile switch
+type Hook struct {
+	Command        string `json:"command"`                  // Run with sh -c, or cmd /C on Windows
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty"` // DefaultHookTimeoutSeconds if zero
+	IncludeKey     bool   `json:"includeKey,omitempty"`     // Also pass the API keys in the environment
+}
+
+// Hooks are the commands run before and after a profile switch
+type Hooks struct {
+	PreSwitch  []Hook `json:"preSwitch,omitempty"`  // A failure aborts the switch
+	PostSwitch []Hook `json:"postSwitch,omitempty"` // Failures are reported after switching
+}
+
+// Timeout returns how long the hook may run before it is killed
+func (h Hook) Timeout() time.Duration {
+	seconds := h.TimeoutSeconds
+	if seconds <= 0 {
+		seconds = DefaultHookTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,27 @@
+package models
+
+import "slices"
+
+// Notification categories shown by the tray
+const (
+	NotifySwitch = "switch" // A profile was activated
+	NotifyError  = "error"  // Switching, enabling or changing auto-start failed
+)
+
+// NotificationCategories lists every notification category
+var NotificationCategories = []string{NotifySwitch, NotifyError}
+
+// NotificationEnabled reports whether notifications of the category are shown
+func (s *Settings) NotificationEnabled(category string) bool {
+	return !slices.Contains(s.MutedNotifications, category)
+}
+
+// SetNotificationEnabled shows or hides notifications of the category
+func (s *Settings) SetNotificationEnabled(category string, enabled bool) {
+	s.MutedNotifications = slices.DeleteFunc(s.MutedNotifications, func(c string) bool {
+		return c == category
+	})
+	if !enabled {
+		s.MutedNotifications = append(s.MutedNotifications, category)
+	}
+}
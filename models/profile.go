@@ -1,26 +1,89 @@
 package models
 
+import (
+	"errors"
+	"hash/fnv"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultClipboardClearSeconds is how long copied secrets stay on the clipboard
+const DefaultClipboardClearSeconds = 30
+
+// ProfileColors are the colours a profile can be shown in
+var ProfileColors = []string{"red", "green", "yellow", "blue", "magenta", "cyan"}
+
 // Profile represents a Claude Code profile configuration
 type Profile struct {
-	Name   string `json:"name"`   // Configuration name for menu display
-	APIURL string `json:"apiUrl"` // API endpoint URL
-	APIKey string `json:"apiKey"` // API authentication key
-	Active bool   `json:"active"` // Whether this is the currently active profile
+	Name   string `json:"name"`            // Configuration name for menu display
+	APIURL string `json:"apiUrl"`          // API endpoint URL
+	APIKey string `json:"apiKey"`          // API authentication key
+	Active bool   `json:"active"`          // Whether this is the currently active profile
+	Color  string `json:"color,omitempty"` // One of ProfileColors; derived from the name if empty
+
+	ConfirmSwitch bool  `json:"confirmSwitch,omitempty"` // Preview changes before activating from the tray
+	Hooks         Hooks `json:"hooks,omitzero"`          // Run when switching to this profile
+}
+
+// DisplayColor returns the profile's colour, picking a stable one from
+// ProfileColors based on the name if none is set
+func (p Profile) DisplayColor() string {
+	if p.Color != "" {
+		return p.Color
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(p.Name))
+	return ProfileColors[h.Sum32()%uint32(len(ProfileColors))]
+}
+
+// Validate checks that the profile has a name, a parseable API URL and an API key
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("配置名称不能为空")
+	}
+
+	if strings.TrimSpace(p.APIURL) == "" {
+		return errors.New("API URL 不能为空")
+	}
+	if _, err := url.Parse(strings.TrimSpace(p.APIURL)); err != nil {
+		return errors.New("API URL 格式无效")
+	}
+
+	if strings.TrimSpace(p.APIKey) == "" {
+		return errors.New("API 密钥不能为空")
+	}
+
+	if p.Color != "" && !slices.Contains(ProfileColors, p.Color) {
+		return errors.New("颜色无效，可选: " + strings.Join(ProfileColors, ", "))
+	}
+
+	return nil
 }
 
 // Settings represents the application settings
 type Settings struct {
-	Enabled   bool      `json:"enabled"`   // Global enable/disable state
-	AutoStart bool      `json:"autoStart"` // Auto-start on system boot
-	Profiles  []Profile `json:"profiles"`  // List of configured profiles
+	Enabled               bool       `json:"enabled"`                      // Global enable/disable state
+	AutoStart             bool       `json:"autoStart"`                    // Auto-start on system boot
+	ClipboardClearSeconds int        `json:"clipboardClearSeconds"`        // Seconds before copied secrets are cleared
+	Profiles              []Profile  `json:"profiles"`                     // List of configured profiles
+	Schedules             []Schedule `json:"schedules,omitempty"`          // Timed profile switches run by the daemon
+	Hooks                 Hooks      `json:"hooks,omitzero"`               // Run around every profile switch
+	Webhooks              []Webhook  `json:"webhooks,omitempty"`           // Notified of switches, additions and deletions
+	MutedNotifications    []string   `json:"mutedNotifications,omitempty"` // Notification categories turned off in the tray
+	TerminalCommand       []string   `json:"terminalCommand,omitempty"`    // Terminal emulator that opens Claude Code, followed by the command to run
+	Gateway               Gateway    `json:"gateway,omitzero"`             // Local proxy that follows the active profile without restarts
 }
 
 // NewSettings creates a new Settings instance with default values
 func NewSettings() *Settings {
 	return &Settings{
-		Enabled:   true,
-		AutoStart: false,
-		Profiles:  []Profile{},
+		Enabled:               true,
+		AutoStart:             false,
+		ClipboardClearSeconds: DefaultClipboardClearSeconds,
+		Profiles:              []Profile{},
 	}
 }
 
@@ -34,9 +97,42 @@ func (s *Settings) GetActiveProfile() *Profile {
 	return nil
 }
 
+// FindProfile returns the profile with the given name, or nil if none
+func (s *Settings) FindProfile(name string) *Profile {
+	for i := range s.Profiles {
+		if s.Profiles[i].Name == name {
+			return &s.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// NextProfile returns the profile after the active one, wrapping around to
+// the first, or nil if there are no profiles
+func (s *Settings) NextProfile() *Profile {
+	if len(s.Profiles) == 0 {
+		return nil
+	}
+	for i := range s.Profiles {
+		if s.Profiles[i].Active {
+			return &s.Profiles[(i+1)%len(s.Profiles)]
+		}
+	}
+	return &s.Profiles[0]
+}
+
 // SetActiveProfile sets the active profile by name and deactivates others
 func (s *Settings) SetActiveProfile(name string) {
 	for i := range s.Profiles {
 		s.Profiles[i].Active = s.Profiles[i].Name == name
 	}
 }
+
+// ClipboardClearDelay returns how long copied secrets stay on the clipboard
+func (s *Settings) ClipboardClearDelay() time.Duration {
+	seconds := s.ClipboardClearSeconds
+	if seconds <= 0 {
+		seconds = DefaultClipboardClearSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// scheduleTimeLayout is the format of Schedule.At
+const scheduleTimeLayout = "15:04"
+
+// weekdayNames are the accepted Schedule.Days values, indexed by time.Weekday
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule activates a profile at a local time of day
+type Schedule struct {
+	At      string   `json:"at"`             // Time of day, e.g. "09:00"
+	Profile string   `json:"profile"`        // Name of the profile to activate
+	Days    []string `json:"days,omitempty"` // Weekdays such as "mon"; every day if empty
+}
+
+// Validate checks the time, profile name and weekdays of the schedule
+func (s Schedule) Validate() error {
+	if _, err := time.Parse(scheduleTimeLayout, s.At); err != nil {
+		return errors.New("计划时间格式无效，应为 HH:MM")
+	}
+
+	if strings.TrimSpace(s.Profile) == "" {
+		return errors.New("计划的配置名称不能为空")
+	}
+
+	for _, day := range s.Days {
+		if !slices.Contains(weekdayNames, strings.ToLower(day)) {
+			return errors.New("计划的星期无效，可选: " + strings.Join(weekdayNames, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Equal reports whether two schedules fire at the same times for the same profile
+func (s Schedule) Equal(other Schedule) bool {
+	return s.At == other.At && s.Profile == other.Profile && slices.Equal(s.Days, other.Days)
+}
+
+// Next returns the first time after the given instant at which the schedule fires
+func (s Schedule) Next(after time.Time) (time.Time, error) {
+	if err := s.Validate(); err != nil {
+		return time.Time{}, err
+	}
+
+	at, _ := time.Parse(scheduleTimeLayout, s.At)
+	for offset := 0; offset <= 7; offset++ {
+		day := after.AddDate(0, 0, offset)
+		next := time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, after.Location())
+		if next.After(after) && s.runsOn(next.Weekday()) {
+			return next, nil
+		}
+	}
+
+	return time.Time{}, errors.New("计划没有可执行的日期")
+}
+
+// runsOn reports whether the schedule fires on the given weekday
+func (s Schedule) runsOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if strings.ToLower(d) == weekdayNames[day] {
+			return true
+		}
+	}
+	return false
+}
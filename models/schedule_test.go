@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-01-03 is a Wednesday
+	base := time.Date(2024, 1, 3, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		want     time.Time
+	}{
+		{"later today", Schedule{At: "18:00", Profile: "p"}, base, time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC)},
+		{"earlier today", Schedule{At: "09:00", Profile: "p"}, base, time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC)},
+		{"exactly now", Schedule{At: "10:30", Profile: "p"}, base, time.Date(2024, 1, 4, 10, 30, 0, 0, time.UTC)},
+		{"seconds past", Schedule{At: "10:30", Profile: "p"}, base.Add(-time.Second), base},
+		{"next weekday", Schedule{At: "09:00", Profile: "p", Days: []string{"fri"}}, base, time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"same weekday next week", Schedule{At: "09:00", Profile: "p", Days: []string{"wed"}}, base, time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)},
+		{"weekday in capitals", Schedule{At: "09:00", Profile: "p", Days: []string{"MON"}}, base, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"across the year", Schedule{At: "00:00", Profile: "p"}, time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := tt.schedule.Next(tt.after)
+		if err != nil {
+			t.Errorf("%s: Next() error = %v", tt.name, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Next() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextLocalTime(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	after := time.Date(2024, 1, 3, 23, 0, 0, 0, loc)
+	got, err := Schedule{At: "08:00", Profile: "p"}.Next(after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 4, 8, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		schedule Schedule
+		valid    bool
+	}{
+		{Schedule{At: "09:00", Profile: "p"}, true},
+		{Schedule{At: "23:59", Profile: "p", Days: []string{"sat", "Sun"}}, true},
+		{Schedule{At: "9am", Profile: "p"}, false},
+		{Schedule{At: "24:00", Profile: "p"}, false},
+		{Schedule{At: "09:00", Profile: " "}, false},
+		{Schedule{At: "09:00", Profile: "p", Days: []string{"monday"}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.schedule.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) error = %v, want valid %v", tt.schedule, err, tt.valid)
+		}
+		if _, nextErr := tt.schedule.Next(time.Now()); (nextErr == nil) != tt.valid {
+			t.Errorf("Next(%+v) error = %v, want valid %v", tt.schedule, nextErr, tt.valid)
+		}
+	}
+}
+
+func TestScheduleEqual(t *testing.T) {
+	base := Schedule{At: "09:00", Profile: "work", Days: []string{"mon", "tue"}}
+	tests := []struct {
+		other Schedule
+		want  bool
+	}{
+		{Schedule{At: "09:00", Profile: "work", Days: []string{"mon", "tue"}}, true},
+		{Schedule{At: "09:30", Profile: "work", Days: []string{"mon", "tue"}}, false},
+		{Schedule{At: "09:00", Profile: "home", Days: []string{"mon", "tue"}}, false},
+		{Schedule{At: "09:00", Profile: "work", Days: []string{"mon"}}, false},
+		{Schedule{At: "09:00", Profile: "work"}, false},
+	}
+	for _, tt := range tests {
+		if got := base.Equal(tt.other); got != tt.want {
+			t.Errorf("Equal(%+v) = %v, want %v", tt.other, got, tt.want)
+		}
+	}
+}
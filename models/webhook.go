@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// WebhookEvents are the events a webhook can receive
+var WebhookEvents = []string{"switch", "add", "delete"}
+
+// Webhook is a URL notified of profile changes
+type Webhook struct {
+	URL    string   `json:"url"`              // Receives a JSON POST per event
+	Secret string   `json:"secret,omitempty"` // Signs payloads with HMAC-SHA256 if set
+	Events []string `json:"events,omitempty"` // Subset of WebhookEvents; all if empty
+}
+
+// Validate checks the URL and event names of the webhook
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Webhook URL 必须是 http 或 https 地址")
+	}
+
+	for _, event := range w.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return errors.New("Webhook 事件无效，可选: " + strings.Join(WebhookEvents, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Wants reports whether the webhook receives the named event
+func (w Webhook) Wants(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
@@ -0,0 +1,186 @@
+// Package redact provides masking of API keys and other secrets before they
+// reach logs, error messages or the user interface
+package redact
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// maskRune separates the visible prefix and suffix of a masked key
+	maskRune = "…"
+	// visibleSuffix is the number of trailing characters kept visible
+	visibleSuffix = 4
+	// minMaskedLength is the shortest key that keeps any visible characters
+	minMaskedLength = 12
+	// minSecretLength is the shortest value Register accepts, so a short
+	// secret cannot mask unrelated text everywhere
+	minSecretLength = 8
+	// maxPending bounds how much of an unterminated line a Writer holds back
+	maxPending = 64 << 10
+)
+
+// vendorPrefixes are the key prefixes MaskKey may keep visible, longest first
+var vendorPrefixes = []string{"sk-ant-", "sk-"}
+
+// MaskKey returns a display-safe form of an API key, e.g. "sk-ant-…9f2c".
+// Only a known vendor prefix and the last few characters are kept, and only
+// while at least half of the key stays hidden.
+func MaskKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	runes := []rune(key)
+	if len(runes) < minMaskedLength {
+		return maskRune
+	}
+
+	prefix := ""
+	for _, p := range vendorPrefixes {
+		if strings.HasPrefix(key, p) {
+			prefix = p
+			break
+		}
+	}
+	if 2*(len([]rune(prefix))+visibleSuffix) > len(runes) {
+		prefix = ""
+	}
+
+	return prefix + maskRune + string(runes[len(runes)-visibleSuffix:])
+}
+
+// urlCredentialsPattern matches the user:pass@ segment of a URL
+var urlCredentialsPattern = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.\-]*://)[^/\s:@]+:[^/\s@]*@`)
+
+// keyPatterns match well-known API key and token formats
+var keyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/\-]{12,}=*`),
+}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = map[string]bool{}
+)
+
+// Register adds secrets, such as configured API keys, that must always be
+// masked wherever they appear. Values shorter than eight characters are
+// ignored.
+func Register(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, v := range values {
+		if len(strings.TrimSpace(v)) >= minSecretLength {
+			secrets[v] = true
+		}
+	}
+}
+
+// String masks every registered secret, well-known key format and URL
+// credential segment in s
+func String(s string) string {
+	secretsMu.RLock()
+	known := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		known = append(known, secret)
+	}
+	secretsMu.RUnlock()
+
+	// Replace longer secrets first so a secret containing another is fully masked
+	sort.Slice(known, func(i, j int) bool { return len(known[i]) > len(known[j]) })
+	for _, secret := range known {
+		s = strings.ReplaceAll(s, secret, MaskKey(secret))
+	}
+
+	s = urlCredentialsPattern.ReplaceAllString(s, "${1}***:***@")
+	for _, pattern := range keyPatterns {
+		s = pattern.ReplaceAllStringFunc(s, maskMatch)
+	}
+
+	return s
+}
+
+// maskMatch masks a pattern match, keeping a leading "Bearer " scheme visible
+func maskMatch(match string) string {
+	if i := strings.IndexAny(match, " \t"); i >= 0 {
+		return match[:i+1] + MaskKey(strings.TrimSpace(match[i+1:]))
+	}
+	return MaskKey(match)
+}
+
+// redactedError wraps an error so its message is redacted while errors.Is
+// and errors.As still see the original error
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Error returns err with a redacted message, or nil if err is nil
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+// Writer redacts everything written through it a line at a time, so a
+// secret split across several writes is still masked
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	pending []byte // Start of a line whose end has not been written yet
+}
+
+// NewWriter returns a writer that redacts complete lines before passing them
+// to w. An unterminated line is held back until it is completed or Flush is
+// called.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (rw *Writer) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.pending = append(rw.pending, p...)
+	end := bytes.LastIndexByte(rw.pending, '\n') + 1
+	if len(rw.pending) > maxPending {
+		end = len(rw.pending)
+	}
+	if end == 0 {
+		return len(p), nil
+	}
+
+	complete := string(rw.pending[:end])
+	rw.pending = append(rw.pending[:0], rw.pending[end:]...)
+	if _, err := io.WriteString(rw.w, String(complete)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush redacts and writes an unterminated line, such as a prompt
+func (rw *Writer) Flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if len(rw.pending) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(rw.w, String(string(rw.pending)))
+	rw.pending = rw.pending[:0]
+	return err
+}
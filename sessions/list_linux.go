@@ -0,0 +1,191 @@
+//go:build linux
+
+package sessions
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clockTicks is the unit of process start times in /proc/<pid>/stat. The
+// kernel reports USER_HZ, which is 100 on every Linux architecture Go supports.
+const clockTicks = 100
+
+// npmPackage appears in the script path when Claude Code runs under Node
+const npmPackage = "@anthropic-ai/claude-code"
+
+// process is the part of /proc/<pid> needed to find sessions
+type process struct {
+	pid     int
+	ppid    int
+	started time.Time
+}
+
+// List returns the Claude Code sessions of the current user, oldest first.
+// Child processes of a session, such as the Node process behind the claude
+// launcher, are reported as part of their parent.
+func List() ([]Session, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return nil, err
+	}
+
+	uid := uint32(os.Getuid())
+	found := make(map[int]process)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes exit while being read, so any error just skips them
+		proc, ok := readProcess(pid, uid, boot)
+		if ok {
+			found[pid] = proc
+		}
+	}
+
+	own, _ := os.Readlink("/proc/self/exe")
+	var sessions []Session
+	for pid, proc := range found {
+		if _, ok := found[proc.ppid]; ok {
+			continue
+		}
+		dir, _ := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+		sessions = append(sessions, Session{PID: pid, Dir: dir, Started: proc.started, Pinned: isExec(proc.ppid, own)})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].Started.Equal(sessions[j].Started) {
+			return sessions[i].Started.Before(sessions[j].Started)
+		}
+		return sessions[i].PID < sessions[j].PID
+	})
+	return sessions, nil
+}
+
+// readProcess reads a process owned by uid and reports whether it is
+// Claude Code
+func readProcess(pid int, uid uint32, boot time.Time) (process, bool) {
+	dir := fmt.Sprintf("/proc/%d", pid)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return process{}, false
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Uid != uid {
+		return process{}, false
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil || !isClaude(strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")) {
+		return process{}, false
+	}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return process{}, false
+	}
+	ppid, ticks, ok := parseStat(stat)
+	if !ok {
+		return process{}, false
+	}
+	started := boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+	return process{pid: pid, ppid: ppid, started: started}, true
+}
+
+// isExec reports whether pid runs the exec subcommand of the executable at
+// own, which starts Claude Code with a profile's credentials in its
+// environment. Sessions started from a shell that exec launched are not
+// recognised and still count as using the active profile.
+func isExec(pid int, own string) bool {
+	if own == "" {
+		return false
+	}
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err != nil || exe != own {
+		return false
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return false
+	}
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	return len(args) > 1 && args[1] == "exec"
+}
+
+// isClaude reports whether a command line runs Claude Code, either the
+// native claude binary or the npm package under Node
+func isClaude(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if filepath.Base(args[0]) == "claude" {
+		return true
+	}
+	if !strings.HasPrefix(filepath.Base(args[0]), "node") || len(args) < 2 {
+		return false
+	}
+	script := args[1]
+	return filepath.Base(script) == "claude" || strings.Contains(script, "/"+npmPackage+"/")
+}
+
+// parseStat extracts the parent PID and start time from /proc/<pid>/stat.
+// The command name in parentheses may itself contain spaces and parentheses,
+// so fields are counted from the last closing parenthesis.
+func parseStat(stat []byte) (ppid int, started uint64, ok bool) {
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, false
+	}
+	// Fields after the name start at field 3 (state); ppid is field 4 and
+	// starttime field 22
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return 0, 0, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	started, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ppid, started, true
+}
+
+// bootTime reads the system boot time from /proc/stat
+func bootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read /proc/stat: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "btime ")
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse boot time: %w", err)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read /proc/stat: %w", err)
+	}
+	return time.Time{}, fmt.Errorf("boot time missing from /proc/stat")
+}
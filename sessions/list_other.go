@@ -0,0 +1,10 @@
+//go:build !linux
+
+package sessions
+
+import "errors"
+
+// List is only implemented on Linux, where processes can be read from /proc
+func List() ([]Session, error) {
+	return nil, errors.ErrUnsupported
+}
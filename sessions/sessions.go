@@ -0,0 +1,112 @@
+// Package sessions finds running Claude Code processes, which keep using the
+// profile they were started with until they are restarted
+package sessions
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often a Tracker rescans while stale sessions remain
+const pollInterval = 5 * time.Second
+
+// startSlack allows for start times that are only known to the second, as
+// on Linux where they are derived from the boot time
+const startSlack = time.Second
+
+// Session is a running Claude Code process
+type Session struct {
+	PID     int
+	Dir     string    // Working directory, empty if it could not be read
+	Started time.Time // Process start time
+	Pinned  bool      // Started by exec with a profile of its own, so switches do not affect it
+}
+
+// StartedBefore returns the sessions started before t that use the active
+// profile. Pinned sessions keep their own profile and are left out, as are
+// sessions started within startSlack of t.
+func StartedBefore(sessions []Session, t time.Time) []Session {
+	var stale []Session
+	for _, s := range sessions {
+		if s.Started.Before(t.Add(-startSlack)) && !s.Pinned {
+			stale = append(stale, s)
+		}
+	}
+	return stale
+}
+
+// Tracker follows the sessions that were already running at the last
+// profile switch until they exit
+type Tracker struct {
+	mu       sync.Mutex
+	switched time.Time
+	stale    []Session
+	polling  bool
+	onChange func()
+}
+
+// NewTracker creates a tracker that calls onChange from its own goroutine
+// whenever the set of stale sessions shrinks
+func NewTracker(onChange func()) *Tracker {
+	return &Tracker{onChange: onChange}
+}
+
+// Switched records a profile switch at the given time and returns the
+// sessions still running with the old profile. It returns nil on platforms
+// where sessions cannot be listed.
+func (t *Tracker) Switched(at time.Time) []Session {
+	all, err := List()
+	if err != nil {
+		return nil
+	}
+	stale := StartedBefore(all, at)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.switched = at
+	t.stale = stale
+	if len(stale) > 0 && !t.polling {
+		t.polling = true
+		go t.poll()
+	}
+	return slices.Clone(stale)
+}
+
+// Stale returns the sessions still running with the profile that was active
+// before the last switch
+func (t *Tracker) Stale() []Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.stale)
+}
+
+// poll rescans until no stale sessions remain
+func (t *Tracker) poll() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		all, err := List()
+
+		t.mu.Lock()
+		changed := false
+		if err == nil {
+			stale := StartedBefore(all, t.switched)
+			changed = !slices.Equal(stale, t.stale)
+			t.stale = stale
+		}
+		done := len(t.stale) == 0
+		if done {
+			t.polling = false
+		}
+		t.mu.Unlock()
+
+		if changed && t.onChange != nil {
+			t.onChange()
+		}
+		if done {
+			return
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package sessions
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStartedBefore(t *testing.T) {
+	switched := time.Date(2024, 1, 3, 10, 0, 0, 500_000_000, time.UTC)
+	all := []Session{
+		{PID: 1, Started: switched.Add(-time.Hour)},
+		{PID: 2, Started: switched.Add(-time.Hour), Pinned: true},
+		// Start times read from /proc are truncated to the second
+		{PID: 3, Started: switched.Truncate(time.Second)},
+		{PID: 4, Started: switched.Add(-startSlack - time.Millisecond)},
+		{PID: 5, Started: switched.Add(time.Minute)},
+	}
+
+	var got []int
+	for _, s := range StartedBefore(all, switched) {
+		got = append(got, s.PID)
+	}
+	if want := []int{1, 4}; !slices.Equal(got, want) {
+		t.Errorf("StartedBefore() = %v, want %v", got, want)
+	}
+}
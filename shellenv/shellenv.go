@@ -0,0 +1,189 @@
+// Package shellenv renders profile environment variables as shell statements
+package shellenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// Var is a single environment variable assignment
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Vars returns the environment variables Claude Code reads for a profile
+func Vars(profile models.Profile) []Var {
+	return []Var{
+		{Name: claude.EnvAuthToken, Value: profile.APIKey},
+		{Name: claude.EnvBaseURL, Value: profile.APIURL},
+	}
+}
+
+// Dialect is a shell syntax that variables can be rendered in
+type Dialect string
+
+const (
+	// Posix renders export statements for bash, zsh and other POSIX shells
+	Posix Dialect = "posix"
+	// Fish renders set -gx statements for the fish shell
+	Fish Dialect = "fish"
+	// PowerShell renders $env: assignments
+	PowerShell Dialect = "powershell"
+	// Dotenv renders KEY="value" lines for .env files
+	Dotenv Dialect = "dotenv"
+)
+
+// dialectNames maps accepted shell names to dialects
+var dialectNames = map[string]Dialect{
+	"bash":       Posix,
+	"zsh":        Posix,
+	"sh":         Posix,
+	"posix":      Posix,
+	"fish":       Fish,
+	"powershell": PowerShell,
+	"pwsh":       PowerShell,
+	"dotenv":     Dotenv,
+}
+
+// ParseDialect returns the dialect for a shell name such as "zsh" or "pwsh"
+func ParseDialect(name string) (Dialect, error) {
+	if d, ok := dialectNames[strings.ToLower(name)]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s", name)
+}
+
+// DetectDialect guesses the dialect of the user's shell from its path, such
+// as the value of $SHELL, falling back to Posix
+func DetectDialect(shellPath string) Dialect {
+	name := strings.TrimSuffix(filepath.Base(shellPath), ".exe")
+	if d, err := ParseDialect(name); err == nil {
+		return d
+	}
+	return Posix
+}
+
+// Render returns statements assigning vars in the given dialect
+func Render(d Dialect, vars []Var) string {
+	var b strings.Builder
+	for _, v := range vars {
+		switch d {
+		case Fish:
+			fmt.Fprintf(&b, "set -gx %s %s\n", v.Name, fishQuote(v.Value))
+		case PowerShell:
+			fmt.Fprintf(&b, "$env:%s = %s\n", v.Name, powerShellQuote(v.Value))
+		case Dotenv:
+			fmt.Fprintf(&b, "%s=%s\n", v.Name, strconv.Quote(v.Value))
+		default:
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, posixQuote(v.Value))
+		}
+	}
+	return b.String()
+}
+
+// RenderUnset returns statements removing vars in the given dialect. Dotenv
+// has no unset syntax, so variables are assigned an empty value instead.
+func RenderUnset(d Dialect, vars []Var) string {
+	var b strings.Builder
+	for _, v := range vars {
+		switch d {
+		case Fish:
+			fmt.Fprintf(&b, "set -e %s\n", v.Name)
+		case PowerShell:
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", v.Name)
+		case Dotenv:
+			fmt.Fprintf(&b, "%s=\n", v.Name)
+		default:
+			fmt.Fprintf(&b, "unset %s\n", v.Name)
+		}
+	}
+	return b.String()
+}
+
+// Quote returns value quoted as a single word in the given dialect
+func Quote(d Dialect, value string) string {
+	switch d {
+	case Fish:
+		return fishQuote(value)
+	case PowerShell:
+		return powerShellQuote(value)
+	case Dotenv:
+		return strconv.Quote(value)
+	default:
+		return posixQuote(value)
+	}
+}
+
+// posixQuote wraps a value in single quotes, escaping embedded single quotes
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote wraps a value in single quotes, escaping backslashes and quotes
+func fishQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+// powerShellQuote wraps a value in single quotes, doubling embedded quotes
+func powerShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// assignmentLine matches a line that sets an environment variable in one of
+// the supported dialects, including csh's setenv
+var assignmentLine = regexp.MustCompile(`^\s*(?:` +
+	`(?:export\s+|declare\s+-x\s+|typeset\s+-x\s+)?[A-Za-z_][A-Za-z0-9_]*=` +
+	`|set\s+(?:-[A-Za-z]+\s+)*[A-Za-z_][A-Za-z0-9_]*\s` +
+	`|setenv\s+[A-Za-z_][A-Za-z0-9_]*\s` +
+	`|\$env:[A-Za-z_][A-Za-z0-9_]*\s*=)`)
+
+// StripFile removes the variable assignments of an env or shell startup file
+// that hold one of secrets and reports whether any line was removed. Other
+// lines are kept even if they contain a secret, such as comments or aliases,
+// so callers should check the file again. The file keeps its permissions.
+func StripFile(path string, secrets []string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !assignmentLine.MatchString(line) || !containsAny(line, secrets) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == len(lines) {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat env file: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(kept, "")), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to write env file: %w", err)
+	}
+
+	return true, nil
+}
+
+// containsAny reports whether s contains any of the non-empty substrings
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if sub != "" && strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
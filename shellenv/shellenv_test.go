@@ -0,0 +1,164 @@
+package shellenv
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		value   string
+		want    string
+	}{
+		{Posix, "sk-ant-abc", `'sk-ant-abc'`},
+		{Posix, "", `''`},
+		{Posix, "it's", `'it'\''s'`},
+		{Posix, `$(rm -rf /) "x" \n`, `'$(rm -rf /) "x" \n'`},
+		{Fish, "it's", `'it\'s'`},
+		{Fish, `a\b`, `'a\\b'`},
+		{Fish, `$HOME`, `'$HOME'`},
+		{PowerShell, "it's", `'it''s'`},
+		{PowerShell, "$env:PATH", `'$env:PATH'`},
+		{Dotenv, `say "hi"`, `"say \"hi\""`},
+		{Dotenv, "a\nb", `"a\nb"`},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.dialect, tt.value); got != tt.want {
+			t.Errorf("Quote(%s, %q) = %s, want %s", tt.dialect, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPosixQuoteRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	for _, value := range []string{"plain", "it's", `'"'"'`, "$(touch pwned) `id` $HOME", "tab\tnew\nline", `back\slash`} {
+		out, err := exec.Command(sh, "-c", "printf %s "+Quote(Posix, value)).Output()
+		if err != nil {
+			t.Fatalf("sh -c error = %v", err)
+		}
+		if string(out) != value {
+			t.Errorf("sh read %q back as %q", value, out)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	vars := []Var{{Name: "ANTHROPIC_AUTH_TOKEN", Value: "k'1"}, {Name: "ANTHROPIC_BASE_URL", Value: "https://a"}}
+	tests := []struct {
+		dialect Dialect
+		want    string
+		unset   string
+	}{
+		{Posix,
+			"export ANTHROPIC_AUTH_TOKEN='k'\\''1'\nexport ANTHROPIC_BASE_URL='https://a'\n",
+			"unset ANTHROPIC_AUTH_TOKEN\nunset ANTHROPIC_BASE_URL\n"},
+		{Fish,
+			"set -gx ANTHROPIC_AUTH_TOKEN 'k\\'1'\nset -gx ANTHROPIC_BASE_URL 'https://a'\n",
+			"set -e ANTHROPIC_AUTH_TOKEN\nset -e ANTHROPIC_BASE_URL\n"},
+		{PowerShell,
+			"$env:ANTHROPIC_AUTH_TOKEN = 'k''1'\n$env:ANTHROPIC_BASE_URL = 'https://a'\n",
+			"Remove-Item Env:ANTHROPIC_AUTH_TOKEN -ErrorAction SilentlyContinue\nRemove-Item Env:ANTHROPIC_BASE_URL -ErrorAction SilentlyContinue\n"},
+		{Dotenv,
+			"ANTHROPIC_AUTH_TOKEN=\"k'1\"\nANTHROPIC_BASE_URL=\"https://a\"\n",
+			"ANTHROPIC_AUTH_TOKEN=\nANTHROPIC_BASE_URL=\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.dialect, vars); got != tt.want {
+			t.Errorf("Render(%s) = %q, want %q", tt.dialect, got, tt.want)
+		}
+		if got := RenderUnset(tt.dialect, vars); got != tt.unset {
+			t.Errorf("RenderUnset(%s) = %q, want %q", tt.dialect, got, tt.unset)
+		}
+	}
+}
+
+func TestDetectDialect(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  Dialect
+	}{
+		{"/bin/zsh", Posix},
+		{"/usr/local/bin/fish", Fish},
+		{"/usr/local/bin/pwsh", PowerShell},
+		{"fish.exe", Fish},
+		{"/bin/tcsh", Posix},
+		{"", Posix},
+	}
+	for _, tt := range tests {
+		if got := DetectDialect(tt.shell); got != tt.want {
+			t.Errorf("DetectDialect(%q) = %s, want %s", tt.shell, got, tt.want)
+		}
+	}
+}
+
+func TestStripFile(t *testing.T) {
+	secrets := []string{"sk-old-1234", "", "sk-other-5678"}
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changed bool
+	}{
+		{"no secrets",
+			"export PATH=/bin\nexport ANTHROPIC_AUTH_TOKEN=\"$(pass show claude)\"\n",
+			"export PATH=/bin\nexport ANTHROPIC_AUTH_TOKEN=\"$(pass show claude)\"\n", false},
+		{"assignment",
+			"export PATH=/bin\nexport ANTHROPIC_AUTH_TOKEN='sk-old-1234'\nalias ll='ls -l'\n",
+			"export PATH=/bin\nalias ll='ls -l'\n", true},
+		{"other assignment holding a secret",
+			"ANTHROPIC_AUTH_TOKEN=sk-other-5678\n  OTHER_TOOL_KEY=\"sk-old-1234\"\nKEEP=1",
+			"KEEP=1", true},
+		{"dialects",
+			"set -gx ANTHROPIC_AUTH_TOKEN sk-old-1234\n$env:ANTHROPIC_AUTH_TOKEN = 'sk-old-1234'\nsetenv ANTHROPIC_AUTH_TOKEN sk-old-1234\ndeclare -x K=sk-old-1234\nKEEP=1\n",
+			"KEEP=1\n", true},
+		{"comments and aliases are kept",
+			"# backup: sk-old-1234\nalias claude-old='ANTHROPIC_AUTH_TOKEN=sk-old-1234 claude'\necho sk-other-5678\n",
+			"# backup: sk-old-1234\nalias claude-old='ANTHROPIC_AUTH_TOKEN=sk-old-1234 claude'\necho sk-other-5678\n", false},
+		{"last line without newline",
+			"KEEP=1\nset -gx ANTHROPIC_AUTH_TOKEN sk-old-1234",
+			"KEEP=1\n", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(tt.in), 0640); err != nil {
+			t.Fatal(err)
+		}
+		before, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed, err := StripFile(path, secrets)
+		if err != nil {
+			t.Fatalf("%s: StripFile() error = %v", tt.name, err)
+		}
+		if changed != tt.changed {
+			t.Errorf("%s: StripFile() = %v, want %v", tt.name, changed, tt.changed)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s: file = %q, want %q", tt.name, data, tt.want)
+		}
+		if after, err := os.Stat(path); err == nil && after.Mode() != before.Mode() {
+			t.Errorf("%s: file mode = %v, want %v", tt.name, after.Mode(), before.Mode())
+		}
+	}
+}
+
+func TestStripFileMissing(t *testing.T) {
+	if _, err := StripFile(filepath.Join(t.TempDir(), "missing"), []string{"x"}); err == nil {
+		t.Error("StripFile() on a missing file succeeded, want error")
+	}
+}
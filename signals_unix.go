@@ -0,0 +1,52 @@
+//go:build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"fyne.io/fyne/v2"
+)
+
+// catchSignals starts catching the control signals, so one sent before the
+// app is ready waits for watchSignals instead of killing the process
+func catchSignals() chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+	return signals
+}
+
+// watchSignals lets window manager keybindings control the running instance:
+// SIGUSR1 cycles to the next profile, SIGUSR2 toggles the enabled state and
+// SIGHUP reloads the settings from disk. The returned function stops watching.
+func watchSignals(signals chan os.Signal) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				log.Printf("收到信号: %v", sig)
+				fyne.Do(func() {
+					switch sig {
+					case syscall.SIGUSR1:
+						cycleProfile()
+					case syscall.SIGUSR2:
+						toggleEnabled()
+					case syscall.SIGHUP:
+						reloadSettings()
+					}
+				})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
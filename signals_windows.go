@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// catchSignals does nothing on Windows, which has no user-defined signals
+func catchSignals() chan os.Signal {
+	return nil
+}
+
+// watchSignals does nothing on Windows, which has no user-defined signals
+func watchSignals(chan os.Signal) (stop func()) {
+	return func() {}
+}
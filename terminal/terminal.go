@@ -0,0 +1,68 @@
+// Package terminal opens Claude Code in a new terminal emulator window
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// ErrNotFound is returned when no terminal command is configured and none of
+// the known terminal emulators is installed
+var ErrNotFound = errors.New("no terminal emulator found")
+
+// candidates are tried in order when no terminal command is configured. Each
+// is followed by the command to run in the new window.
+var candidates = [][]string{
+	{"x-terminal-emulator", "-e"},
+	{"gnome-terminal", "--"},
+	{"konsole", "-e"},
+	{"kitty"},
+	{"wezterm", "start", "--"},
+	{"alacritty", "-e"},
+	{"xterm", "-e"},
+}
+
+// Command returns the configured terminal command, or the first installed
+// terminal emulator if none is configured
+func Command(configured []string) ([]string, error) {
+	if len(configured) > 0 {
+		return configured, nil
+	}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return candidate, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// OpenClaude starts claude in dir inside a new terminal window. With a
+// profile name it runs through the exec subcommand of this executable, so
+// the session gets that profile's environment whatever settings.json holds.
+func OpenClaude(terminal []string, dir, profile string) error {
+	if len(terminal) == 0 {
+		return ErrNotFound
+	}
+
+	args := slices.Clone(terminal[1:])
+	if profile != "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find executable: %w", err)
+		}
+		args = append(args, executable, "exec", "-profile", profile, "--")
+	}
+	args = append(args, "claude")
+
+	cmd := exec.Command(terminal[0], args...)
+	cmd.Dir = dir
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start terminal: %w", err)
+	}
+	// Reap the terminal process once its window is closed
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,300 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"golang.org/x/text/width"
+)
+
+// Terminal control sequences
+const (
+	enterScreen = "\x1b[?1049h\x1b[H"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleMatch   = "\x1b[1;33m"
+	styleOK      = "\x1b[32m"
+	styleError   = "\x1b[31m"
+)
+
+const (
+	// headerHeight is the title, search and separator rows above the list
+	headerHeight = 3
+	// detailHeight is the rows below the list separator used by the preview
+	// or the edit form
+	detailHeight = 5
+	// footerHeight is the message and key help rows
+	footerHeight = 2
+)
+
+// segment is a run of text drawn in one style
+type segment struct {
+	text  string
+	style string
+}
+
+// row is a single screen line; base is applied underneath every segment
+type row struct {
+	segments []segment
+	base     string
+}
+
+// render returns the row clipped and padded to exactly width columns.
+// Control characters are replaced so profile data cannot inject escapes.
+func (r row) render(cols int) string {
+	var b strings.Builder
+	used := 0
+
+segments:
+	for _, s := range r.segments {
+		b.WriteString(styleReset + r.base + s.style)
+		for _, c := range s.text {
+			if c < 0x20 || c == 0x7f {
+				c = '?'
+			}
+			w := runeWidth(c)
+			if used+w > cols {
+				break segments
+			}
+			b.WriteRune(c)
+			used += w
+		}
+	}
+
+	b.WriteString(styleReset + r.base + strings.Repeat(" ", cols-used) + styleReset)
+	return b.String()
+}
+
+// runeWidth returns the number of columns r occupies
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// stringWidth returns the number of columns s occupies
+func stringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// listHeight returns the number of rows available for matches
+func (p *picker) listHeight() int {
+	return max(1, p.height-headerHeight-1-detailHeight-footerHeight)
+}
+
+// draw writes a full frame and leaves the cursor at the input position
+func (p *picker) draw(w io.Writer) error {
+	rows := p.layout()
+
+	var b strings.Builder
+	b.WriteString(hideCursor)
+	for i := 0; i < p.height; i++ {
+		fmt.Fprintf(&b, "\x1b[%d;1H", i+1)
+		if i < len(rows) {
+			b.WriteString(rows[i].render(p.width))
+		} else {
+			b.WriteString(row{}.render(p.width))
+		}
+	}
+
+	line, col := p.cursorPosition()
+	fmt.Fprintf(&b, "\x1b[%d;%dH%s", line, col, showCursor)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// layout returns the rows of the current frame from top to bottom
+func (p *picker) layout() []row {
+	rows := []row{p.titleRow(), p.searchRow(), p.separator()}
+
+	height := p.listHeight()
+	p.scroll(height)
+	for i := 0; i < height; i++ {
+		switch idx := p.offset + i; {
+		case idx < len(p.matches):
+			rows = append(rows, p.matchRow(idx))
+		case i == 0:
+			rows = append(rows, row{segments: []segment{{"  没有匹配的配置", styleDim}}})
+		default:
+			rows = append(rows, row{})
+		}
+	}
+
+	rows = append(rows, p.separator())
+	if p.form != nil {
+		rows = append(rows, p.formRows()...)
+	} else {
+		rows = append(rows, p.previewRows()...)
+	}
+
+	return append(rows, p.messageRow(), p.helpRow())
+}
+
+// cursorPosition returns the 1-based line and column of the text cursor
+func (p *picker) cursorPosition() (int, int) {
+	if p.form != nil {
+		line := headerHeight + p.listHeight() + 3 + p.form.focus
+		return line, stringWidth(p.form.prefix(p.form.focus)+p.form.display(p.form.focus)) + 1
+	}
+	return 2, stringWidth("> "+string(p.query)) + 1
+}
+
+// titleRow shows the global enabled state and the active profile
+func (p *picker) titleRow() row {
+	settings := p.manager.GetSettings()
+
+	state := segment{"已启用", styleOK}
+	if !settings.Enabled {
+		state = segment{"已禁用", styleError}
+	}
+
+	active := "(无)"
+	if profile := settings.GetActiveProfile(); profile != nil {
+		active = profile.Name
+	}
+
+	return row{segments: []segment{
+		{" cc-quick-profile", styleBold},
+		{"  全局状态: ", styleDim},
+		state,
+		{"  活动配置: ", styleDim},
+		{active, ""},
+	}}
+}
+
+// searchRow shows the query and the number of matches
+func (p *picker) searchRow() row {
+	return row{segments: []segment{
+		{"> ", styleBold},
+		{string(p.query), ""},
+		{fmt.Sprintf("  %d/%d", len(p.matches), len(p.manager.GetSettings().Profiles)), styleDim},
+	}}
+}
+
+// separator is a horizontal rule across the screen
+func (p *picker) separator() row {
+	return row{segments: []segment{{strings.Repeat("-", p.width), styleDim}}}
+}
+
+// matchRow shows one match, highlighting the runes that matched the query
+func (p *picker) matchRow(idx int) row {
+	m := p.matches[idx]
+
+	r := row{segments: []segment{{"  ", ""}}}
+	if idx == p.cursor {
+		r = row{segments: []segment{{"> ", styleBold}}, base: styleReverse}
+	}
+
+	if m.profile.Active {
+		r.segments = append(r.segments, segment{"* ", styleOK})
+	} else {
+		r.segments = append(r.segments, segment{"  ", ""})
+	}
+
+	r.segments = append(r.segments, highlight([]rune(m.profile.Name), m.positions)...)
+	r.segments = append(r.segments, segment{"  " + hostOf(m.profile.APIURL), styleDim})
+	return r
+}
+
+// highlight splits name into segments, styling the runes at positions
+func highlight(name []rune, positions []int) []segment {
+	matched := make(map[int]bool, len(positions))
+	for _, i := range positions {
+		matched[i] = true
+	}
+
+	segments := []segment{}
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		if i < len(name) && matched[i] == matched[start] {
+			continue
+		}
+		style := ""
+		if matched[start] {
+			style = styleMatch
+		}
+		segments = append(segments, segment{string(name[start:i]), style})
+		start = i
+	}
+	return segments
+}
+
+// previewRows describe the selected profile without revealing its key
+func (p *picker) previewRows() []row {
+	m := p.selected()
+	if m == nil {
+		return make([]row, detailHeight)
+	}
+
+	status := "未激活"
+	if m.profile.Active {
+		status = "活动"
+	}
+
+	return []row{
+		labelRow("名称", m.profile.Name),
+		labelRow("主机", hostOf(m.profile.APIURL)),
+		labelRow("认证方式", fmt.Sprintf("%s (%s)", authMode(m.profile.APIKey), claude.EnvAuthToken)),
+		labelRow("API 密钥", redact.MaskKey(m.profile.APIKey)),
+		labelRow("状态", status),
+	}
+}
+
+// labelRow formats a "label: value" preview line
+func labelRow(label, value string) row {
+	return row{segments: []segment{{" " + label + ": ", styleDim}, {value, ""}}}
+}
+
+// formRows show the edit form
+func (p *picker) formRows() []row {
+	f := p.form
+	rows := []row{{segments: []segment{{" 编辑配置: ", styleBold}, {f.original, styleBold}}}}
+
+	for i := range f.fields {
+		r := row{segments: []segment{{f.prefix(i), styleDim}, {f.display(i), ""}}}
+		if i == f.focus {
+			r.segments[0].style = styleBold
+		}
+		rows = append(rows, r)
+	}
+
+	return append(rows, row{segments: []segment{{"   API 密钥留空则保持不变", styleDim}}})
+}
+
+// messageRow shows the result of the last action
+func (p *picker) messageRow() row {
+	if p.message == "" {
+		return row{}
+	}
+
+	style := styleOK
+	if p.failed {
+		style = styleError
+	}
+	return row{segments: []segment{{" " + p.message, style}}}
+}
+
+// helpRow lists the key bindings of the current mode
+func (p *picker) helpRow() row {
+	help := " 方向键 选择  Enter 激活  Ctrl-T 启用/禁用  Ctrl-E 编辑  Ctrl-U 清空搜索  Esc 退出"
+	if p.form != nil {
+		help = " Tab 切换字段  Enter 保存  Ctrl-U 清空字段  Esc 取消"
+	}
+	return row{segments: []segment{{help, styleDim}}}
+}
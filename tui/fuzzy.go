@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// match is a profile that satisfies the search query
+type match struct {
+	profile   models.Profile
+	score     int
+	positions []int // Rune indices of the name that matched the query
+}
+
+// filterProfiles returns the profiles matching query, best match first.
+// Names are searched first; a profile whose host matches ranks lower and
+// has no highlighted positions.
+func filterProfiles(profiles []models.Profile, query string) []match {
+	pattern := []rune(strings.TrimSpace(query))
+	matches := []match{}
+
+	for _, p := range profiles {
+		if score, positions, ok := fuzzyMatch(pattern, []rune(p.Name)); ok {
+			matches = append(matches, match{profile: p, score: score, positions: positions})
+			continue
+		}
+		if score, _, ok := fuzzyMatch(pattern, []rune(hostOf(p.APIURL))); ok {
+			matches = append(matches, match{profile: p, score: score / 2})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case. The score rewards consecutive runes and word starts and
+// penalises gaps between the first and last matched rune.
+func fuzzyMatch(pattern, text []rune) (score int, positions []int, ok bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+
+	positions = make([]int, 0, len(pattern))
+	for i, r := range text {
+		if len(positions) == len(pattern) {
+			break
+		}
+		if unicode.ToLower(r) != unicode.ToLower(pattern[len(positions)]) {
+			continue
+		}
+
+		score++
+		if n := len(positions); n > 0 && positions[n-1] == i-1 {
+			score += 4
+		}
+		if i == 0 || !isWordRune(text[i-1]) {
+			score += 3
+		}
+		positions = append(positions, i)
+	}
+
+	if len(positions) < len(pattern) {
+		return 0, nil, false
+	}
+
+	score -= positions[len(positions)-1] - positions[0] + 1 - len(pattern)
+	return score, positions, true
+}
+
+// isWordRune reports whether r is part of a word rather than a separator
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hostOf returns the host of an API URL, or the URL itself if it cannot be parsed
+func hostOf(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return apiURL
+	}
+	return u.Host
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		text      string
+		ok        bool
+		positions []int
+	}{
+		{"empty pattern", "", "work", true, nil},
+		{"exact", "work", "work", true, []int{0, 1, 2, 3}},
+		{"subsequence", "wk", "work", true, []int{0, 3}},
+		{"upper case pattern", "WORK", "work", true, []int{0, 1, 2, 3}},
+		{"upper case text", "api", "Prod-API", true, []int{5, 6, 7}},
+		{"non-ASCII case folding", "äb", "ÄBC", true, []int{0, 1}},
+		{"wrong order", "kw", "work", false, nil},
+		{"missing rune", "wx", "work", false, nil},
+		{"longer than text", "works", "work", false, nil},
+		{"CJK", "工作", "我的工作", true, []int{2, 3}},
+		{"CJK subsequence", "公司", "公司账号", true, []int{0, 1}},
+		{"CJK mixed", "测k", "测试key", true, []int{0, 2}},
+		{"CJK non-match", "个人", "我的工作", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, positions, ok := fuzzyMatch([]rune(tt.pattern), []rune(tt.text))
+			if ok != tt.ok {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.pattern, tt.text, ok, tt.ok)
+			}
+			if !slices.Equal(positions, tt.positions) {
+				t.Errorf("fuzzyMatch(%q, %q) positions = %v, want %v", tt.pattern, tt.text, positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	// Each pair is a query and two names, the first of which must rank higher
+	tests := []struct {
+		name          string
+		pattern       string
+		better, worse string
+	}{
+		{"consecutive over scattered", "wk", "wk-test", "work"},
+		{"word start over middle", "api", "prod-api", "rapid"},
+		{"short gap over long gap", "ab", "a-b", "a---b"},
+		{"case does not matter", "WORK", "work", "w-o-r-k"},
+		{"CJK consecutive over scattered", "工作", "工作账号", "工具合作"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, _, ok := fuzzyMatch([]rune(tt.pattern), []rune(tt.better))
+			if !ok {
+				t.Fatalf("%q does not match %q", tt.pattern, tt.better)
+			}
+			worse, _, ok := fuzzyMatch([]rune(tt.pattern), []rune(tt.worse))
+			if !ok {
+				t.Fatalf("%q does not match %q", tt.pattern, tt.worse)
+			}
+			if better <= worse {
+				t.Errorf("score(%q) = %d, want more than score(%q) = %d", tt.better, better, tt.worse, worse)
+			}
+		})
+	}
+}
+
+func TestFilterProfiles(t *testing.T) {
+	profiles := []models.Profile{
+		{Name: "work", APIURL: "https://api.example.com"},
+		{Name: "personal", APIURL: "https://api.example.com"},
+		{Name: "prod", APIURL: "https://api.workhost.com/v1"},
+		{Name: "wk-test", APIURL: "https://api.example.com"},
+		{Name: "我的工作", APIURL: "https://api.example.com"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty keeps the order", "  ", []string{"work", "personal", "prod", "wk-test", "我的工作"}},
+		{"best match first, host matches last", "wk", []string{"wk-test", "work", "prod"}},
+		{"case folding", "PERS", []string{"personal"}},
+		{"CJK", "工作", []string{"我的工作"}},
+		{"no match", "zzz", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, m := range filterProfiles(profiles, tt.query) {
+				got = append(got, m.profile.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterProfiles(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	// A profile found by its host has nothing to highlight in its name
+	for _, m := range filterProfiles(profiles, "wk") {
+		if m.profile.Name == "prod" && m.positions != nil {
+			t.Errorf("host match positions = %v, want none", m.positions)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+// keyKind identifies a decoded key press
+type keyKind int
+
+const (
+	keyNone keyKind = iota
+	keyRune
+	keyEnter
+	keyEsc
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyTab
+	keyBackTab
+	keyBackspace
+	keyCtrlC
+	keyCtrlE
+	keyCtrlT
+	keyCtrlU
+)
+
+// key is a single key press read from the terminal
+type key struct {
+	kind keyKind
+	r    rune // Character typed, for keyRune
+}
+
+// controlKeys maps control bytes to keys
+var controlKeys = map[byte]keyKind{
+	0x03: keyCtrlC, // Ctrl-C
+	0x04: keyCtrlC, // Ctrl-D
+	0x05: keyCtrlE,
+	0x0e: keyDown, // Ctrl-N
+	0x10: keyUp,   // Ctrl-P
+	0x14: keyCtrlT,
+	0x15: keyCtrlU,
+}
+
+// escapeKeys maps CSI and SS3 sequences, without the leading "ESC [" or
+// "ESC O", to keys
+var escapeKeys = map[string]keyKind{
+	"A":  keyUp,
+	"B":  keyDown,
+	"H":  keyHome,
+	"F":  keyEnd,
+	"Z":  keyBackTab,
+	"1~": keyHome,
+	"7~": keyHome,
+	"4~": keyEnd,
+	"8~": keyEnd,
+	"5~": keyPageUp,
+	"6~": keyPageDown,
+}
+
+// parseKeys decodes the bytes of a single terminal read into key presses
+func parseKeys(b []byte) []key {
+	keys := []key{}
+	for len(b) > 0 {
+		k, n := parseKey(b)
+		b = b[n:]
+		if k.kind != keyNone {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// parseKey decodes the key at the start of b and returns it with the number
+// of bytes consumed
+func parseKey(b []byte) (key, int) {
+	switch c := b[0]; {
+	case c == 0x1b:
+		if len(b) > 1 && (b[1] == '[' || b[1] == 'O') {
+			return parseEscape(b)
+		}
+		return key{kind: keyEsc}, 1
+	case c == '\r' || c == '\n':
+		return key{kind: keyEnter}, 1
+	case c == 0x7f || c == 0x08:
+		return key{kind: keyBackspace}, 1
+	case c == '\t':
+		return key{kind: keyTab}, 1
+	case c < 0x20:
+		return key{kind: controlKeys[c]}, 1
+	}
+
+	r, n := utf8.DecodeRune(b)
+	if r == utf8.RuneError {
+		return key{}, n
+	}
+	return key{kind: keyRune, r: r}, n
+}
+
+// parseEscape decodes an escape sequence, skipping sequences it does not know
+func parseEscape(b []byte) (key, int) {
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return key{kind: escapeKeys[string(b[2:i+1])]}, i + 1
+		}
+	}
+	return key{}, len(b)
+}
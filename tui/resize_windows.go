@@ -0,0 +1,9 @@
+//go:build windows
+
+package tui
+
+import "os"
+
+// notifyResize does nothing on Windows, which has no resize signal; the
+// terminal size is re-read before every redraw instead
+func notifyResize(ch chan<- os.Signal) {}
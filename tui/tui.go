@@ -0,0 +1,398 @@
+// Package tui implements a full-screen terminal profile picker for sessions
+// without a system tray
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/redact"
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned by Run when stdin or stdout is not a terminal
+var ErrNotTerminal = errors.New("not a terminal")
+
+// Edit form fields
+const (
+	fieldName = iota
+	fieldURL
+	fieldKey
+)
+
+// fieldLabels are the edit form labels, indexed by field
+var fieldLabels = [...]string{"名称", "API URL", "API 密钥"}
+
+// picker holds the state of the terminal UI
+type picker struct {
+	manager *config.Manager
+	width   int
+	height  int
+
+	query   []rune
+	matches []match
+	cursor  int // Index of the selected match
+	offset  int // Index of the first visible match
+
+	message string // Result of the last action
+	failed  bool   // Whether message describes an error
+
+	form      *editForm
+	activated string // Profile activated with Enter
+	hookErr   error  // Post-switch hook failure of that activation
+
+	suspend func() // Restores the terminal, if set
+	resume  func() // Takes the terminal back after suspend, if set
+}
+
+// editForm holds the values being edited for a profile
+type editForm struct {
+	original string // Name of the profile being edited
+	fields   [len(fieldLabels)][]rune
+	focus    int
+}
+
+// Run shows the picker until the user activates a profile or quits. It
+// returns the name of the activated profile, or "" if the user quit. A
+// profile is still returned with an error wrapping config.ErrPostSwitchHook.
+func Run(manager *config.Manager, in, out *os.File) (string, error) {
+	inFd, outFd := int(in.Fd()), int(out.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return "", ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return "", fmt.Errorf("failed to enable raw mode: %w", err)
+	}
+	defer func() { term.Restore(inFd, state) }()
+
+	w := bufio.NewWriter(out)
+	w.WriteString(enterScreen)
+	defer func() {
+		w.WriteString(leaveScreen)
+		w.Flush()
+	}()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	p := &picker{manager: manager}
+	p.suspend = func() {
+		w.WriteString(leaveScreen)
+		w.Flush()
+		term.Restore(inFd, state)
+	}
+	p.resume = func() {
+		if raw, err := term.MakeRaw(inFd); err == nil {
+			state = raw
+		}
+		w.WriteString(enterScreen)
+	}
+	p.filter()
+	keys := readKeys(in)
+
+	for {
+		p.width, p.height = terminalSize(outFd)
+		if err := p.draw(w); err != nil {
+			return "", fmt.Errorf("failed to draw: %w", err)
+		}
+		if err := w.Flush(); err != nil {
+			return "", fmt.Errorf("failed to draw: %w", err)
+		}
+
+		select {
+		case batch, ok := <-keys:
+			if !ok {
+				return "", nil
+			}
+			for _, k := range batch {
+				if p.handle(k) {
+					return p.activated, p.hookErr
+				}
+			}
+		case <-resize:
+		}
+	}
+}
+
+// readKeys decodes key presses from in until it is closed or fails
+func readKeys(in *os.File) <-chan []key {
+	keys := make(chan []key)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				keys <- parseKeys(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return keys
+}
+
+// terminalSize returns the terminal's columns and rows, defaulting to 80x24
+func terminalSize(fd int) (int, int) {
+	cols, rows, err := term.GetSize(fd)
+	if err != nil || cols <= 0 || rows <= 0 {
+		return 80, 24
+	}
+	return cols, rows
+}
+
+// handle applies a key press and reports whether the picker should exit
+func (p *picker) handle(k key) bool {
+	p.message, p.failed = "", false
+
+	if p.form != nil {
+		p.handleForm(k)
+		return false
+	}
+
+	switch k.kind {
+	case keyEsc, keyCtrlC:
+		return true
+	case keyEnter:
+		return p.activate()
+	case keyUp:
+		p.move(-1)
+	case keyDown:
+		p.move(1)
+	case keyPageUp:
+		p.move(-p.listHeight())
+	case keyPageDown:
+		p.move(p.listHeight())
+	case keyHome:
+		p.move(-len(p.matches))
+	case keyEnd:
+		p.move(len(p.matches))
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyCtrlU:
+		p.query = nil
+		p.filter()
+	case keyCtrlT:
+		p.toggleEnabled()
+	case keyCtrlE:
+		p.openForm()
+	case keyRune:
+		p.query = append(p.query, k.r)
+		p.filter()
+	}
+	return false
+}
+
+// filter re-runs the search and selects the best match
+func (p *picker) filter() {
+	p.matches = filterProfiles(p.manager.GetSettings().Profiles, string(p.query))
+	p.cursor, p.offset = 0, 0
+}
+
+// refresh re-runs the search after the profiles changed, keeping name selected
+func (p *picker) refresh(name string) {
+	p.filter()
+	for i, m := range p.matches {
+		if m.profile.Name == name {
+			p.cursor = i
+		}
+	}
+}
+
+// selected returns the match under the cursor, or nil if nothing matches
+func (p *picker) selected() *match {
+	if p.cursor < len(p.matches) {
+		return &p.matches[p.cursor]
+	}
+	return nil
+}
+
+// move shifts the cursor by delta, clamped to the matches
+func (p *picker) move(delta int) {
+	p.cursor = min(max(p.cursor+delta, 0), max(len(p.matches)-1, 0))
+}
+
+// scroll adjusts the offset so the cursor is within the visible rows
+func (p *picker) scroll(height int) {
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+height {
+		p.offset = p.cursor - height + 1
+	}
+}
+
+// setError shows an error message with secrets removed
+func (p *picker) setError(format string, a ...interface{}) {
+	p.message = redact.String(fmt.Sprintf(format, a...))
+	p.failed = true
+}
+
+// activate makes the selected profile active and reports whether to exit
+func (p *picker) activate() bool {
+	m := p.selected()
+	if m == nil {
+		return false
+	}
+
+	// Switch hooks run with the terminal restored, as they would from the
+	// shell. The picker only comes back if the switch failed.
+	if p.suspend != nil {
+		p.suspend()
+	}
+	err := p.manager.SetActiveProfile(m.profile.Name)
+	if errors.Is(err, config.ErrPostSwitchHook) {
+		p.hookErr = err
+	} else if err != nil {
+		if p.resume != nil {
+			p.resume()
+		}
+		p.setError("设置活动配置失败: %v", err)
+		return false
+	}
+
+	p.activated = m.profile.Name
+	return true
+}
+
+// toggleEnabled flips the global enabled state
+func (p *picker) toggleEnabled() {
+	enabled := !p.manager.GetSettings().Enabled
+	if err := p.manager.SetEnabled(enabled); err != nil {
+		p.setError("更新启用状态失败: %v", err)
+		return
+	}
+
+	p.message = "已禁用"
+	if enabled {
+		p.message = "已启用"
+	}
+	if m := p.selected(); m != nil {
+		p.refresh(m.profile.Name)
+	}
+}
+
+// openForm starts editing the selected profile
+func (p *picker) openForm() {
+	m := p.selected()
+	if m == nil {
+		return
+	}
+
+	p.form = &editForm{original: m.profile.Name}
+	p.form.fields[fieldName] = []rune(m.profile.Name)
+	p.form.fields[fieldURL] = []rune(m.profile.APIURL)
+}
+
+// handleForm applies a key press to the edit form
+func (p *picker) handleForm(k key) {
+	f := p.form
+	switch k.kind {
+	case keyEsc, keyCtrlC:
+		p.form = nil
+		p.message = "已取消编辑"
+	case keyEnter:
+		p.saveForm()
+	case keyTab, keyDown:
+		f.focus = (f.focus + 1) % len(f.fields)
+	case keyBackTab, keyUp:
+		f.focus = (f.focus + len(f.fields) - 1) % len(f.fields)
+	case keyBackspace:
+		if field := f.fields[f.focus]; len(field) > 0 {
+			f.fields[f.focus] = field[:len(field)-1]
+		}
+	case keyCtrlU:
+		f.fields[f.focus] = nil
+	case keyRune:
+		f.fields[f.focus] = append(f.fields[f.focus], k.r)
+	}
+}
+
+// saveForm validates and stores the edited profile, re-applying it to
+// Claude Code if it is active
+func (p *picker) saveForm() {
+	f := p.form
+	settings := p.manager.GetSettings()
+
+	existing := settings.FindProfile(f.original)
+	if existing == nil {
+		p.form = nil
+		p.setError("编辑配置失败: %v: '%s'", config.ErrProfileNotFound, f.original)
+		p.filter()
+		return
+	}
+
+	updated := *existing
+	updated.Name = strings.TrimSpace(string(f.fields[fieldName]))
+	updated.APIURL = strings.TrimSpace(string(f.fields[fieldURL]))
+	if key := strings.TrimSpace(string(f.fields[fieldKey])); key != "" {
+		updated.APIKey = key
+	}
+
+	if err := updated.Validate(); err != nil {
+		p.setError("%v", err)
+		return
+	}
+	if updated.Name != f.original && settings.FindProfile(updated.Name) != nil {
+		p.setError("编辑配置失败: %v: '%s'", config.ErrProfileExists, updated.Name)
+		return
+	}
+
+	if err := p.manager.UpdateProfile(f.original, updated); err != nil {
+		p.setError("编辑配置失败: %v", err)
+		return
+	}
+	// An edit is not a switch, so the active profile is rewritten without
+	// running the switch hooks
+	if updated.Active {
+		if err := p.manager.RefreshCredentials(); err != nil {
+			p.setError("更新 Claude Code 设置失败: %v", err)
+			return
+		}
+	}
+
+	p.form = nil
+	p.message = fmt.Sprintf("配置 '%s' 已更新", updated.Name)
+	p.refresh(updated.Name)
+}
+
+// prefix returns the label shown before a form field
+func (f *editForm) prefix(field int) string {
+	marker := "  "
+	if field == f.focus {
+		marker = "> "
+	}
+	return " " + marker + fieldLabels[field] + ": "
+}
+
+// display returns a form field's value, hiding the API key
+func (f *editForm) display(field int) string {
+	if field == fieldKey {
+		return strings.Repeat("*", len(f.fields[field]))
+	}
+	return string(f.fields[field])
+}
+
+// authMode describes how a key authenticates, judged by its prefix
+func authMode(apiKey string) string {
+	switch {
+	case strings.HasPrefix(apiKey, "sk-ant-oat"):
+		return "OAuth 令牌"
+	case strings.HasPrefix(apiKey, "sk-ant-api"):
+		return "Anthropic API 密钥"
+	default:
+		return "Bearer 令牌"
+	}
+}
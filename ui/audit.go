@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ipfans/cc-quick-profile/audit"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// auditColumns are the column headers of the audit log table
+var auditColumns = []string{"时间", "配置", "操作", "目标文件", "密钥哈希"}
+
+// auditColumnWidths are the initial widths of the audit log table columns
+var auditColumnWidths = []float32{160, 120, 80, 280, 160}
+
+// ShowAuditWindow displays the credential change audit log, newest first
+func ShowAuditWindow(app fyne.App, configManager *config.Manager) {
+	window := app.NewWindow("审计日志")
+	window.Resize(fyne.NewSize(820, 420))
+	window.CenterOnScreen()
+
+	entries, err := configManager.AuditEntries()
+	if err != nil {
+		window.SetContent(container.NewPadded(widget.NewLabel(fmt.Sprintf("读取审计日志失败: %v", redact.Error(err)))))
+		window.Show()
+		return
+	}
+
+	// Reverse so the most recent change is shown first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(entries), len(auditColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			cell.(*widget.Label).SetText(auditCell(entries[id.Row], id.Col))
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		if id.Col >= 0 {
+			cell.(*widget.Label).SetText(auditColumns[id.Col])
+		}
+	}
+	for i, width := range auditColumnWidths {
+		table.SetColumnWidth(i, width)
+	}
+
+	var content fyne.CanvasObject = table
+	if len(entries) == 0 {
+		content = widget.NewLabel("暂无审计记录")
+	}
+
+	window.SetContent(container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("共 %d 条记录", len(entries))),
+		nil, nil, nil,
+		content,
+	))
+	window.Show()
+}
+
+// auditCell returns the display text for a column of an audit entry
+func auditCell(entry audit.Entry, col int) string {
+	switch col {
+	case 0:
+		return entry.Time.Local().Format("2006-01-02 15:04:05")
+	case 1:
+		return entry.Profile
+	case 2:
+		return string(entry.Action)
+	case 3:
+		return entry.Target
+	case 4:
+		return shortHash(entry.KeyHash)
+	}
+	return ""
+}
+
+// shortHash abbreviates a key hash for table display
+func shortHash(hash string) string {
+	const visible = 19 // "sha256:" plus 12 hex digits
+	if len(hash) <= visible {
+		return hash
+	}
+	return hash[:visible] + "…"
+}
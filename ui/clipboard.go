@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// CopySecret puts a secret on the system clipboard and clears it after the
+// given delay, unless the clipboard content has been replaced in the meantime
+func CopySecret(app fyne.App, secret string, clearAfter time.Duration) {
+	clipboard := app.Clipboard()
+	clipboard.SetContent(secret)
+
+	time.AfterFunc(clearAfter, func() {
+		fyne.Do(func() {
+			if clipboard.Content() == secret {
+				clipboard.SetContent("")
+			}
+		})
+	})
+}
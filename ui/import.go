@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// ShowImportDialog asks the user to confirm profiles offered by a link
+// before anything is saved. Profiles whose names are taken are skipped, and
+// a key must be entered for those that came without one.
+func ShowImportDialog(app fyne.App, configManager *config.Manager, profiles []models.Profile) {
+	window := app.NewWindow("导入配置")
+	window.Resize(fyne.NewSize(520, 360))
+	window.CenterOnScreen()
+
+	settings := configManager.GetSettings()
+	keyEntries := map[string]*widget.Entry{}
+	newProfiles := []models.Profile{}
+
+	rows := container.NewVBox()
+	for _, profile := range profiles {
+		label := widget.NewLabel(fmt.Sprintf("%s  (%s)", profile.Name, redact.String(profile.APIURL)))
+		label.TextStyle = fyne.TextStyle{Bold: true}
+
+		var status fyne.CanvasObject
+		switch {
+		case settings.FindProfile(profile.Name) != nil:
+			status = widget.NewLabel("已存在同名配置，将跳过")
+		case profile.APIKey != "":
+			status = widget.NewLabel("包含 API 密钥: " + redact.MaskKey(profile.APIKey))
+			newProfiles = append(newProfiles, profile)
+		default:
+			entry := widget.NewPasswordEntry()
+			entry.SetPlaceHolder("请输入 API 密钥")
+			keyEntries[profile.Name] = entry
+			status = entry
+			newProfiles = append(newProfiles, profile)
+		}
+
+		rows.Add(container.NewVBox(label, status, widget.NewSeparator()))
+	}
+
+	errorLabel := widget.NewLabel("")
+	errorLabel.Wrapping = fyne.TextWrapWord
+	errorLabel.Hide()
+
+	importButton := widget.NewButton(fmt.Sprintf("导入 %d 个配置", len(newProfiles)), func() {
+		// Validate everything before saving anything
+		for i := range newProfiles {
+			if entry, ok := keyEntries[newProfiles[i].Name]; ok {
+				newProfiles[i].APIKey = strings.TrimSpace(entry.Text)
+			}
+			if err := newProfiles[i].Validate(); err != nil {
+				errorLabel.SetText(fmt.Sprintf("配置 '%s': %v", newProfiles[i].Name, redact.Error(err)))
+				errorLabel.Show()
+				return
+			}
+		}
+
+		// Drop saved profiles so a retry after an error does not add them twice
+		for len(newProfiles) > 0 {
+			profile := newProfiles[0]
+			if err := configManager.AddProfile(profile); err != nil {
+				errorLabel.SetText(fmt.Sprintf("导入配置 '%s' 失败: %v", profile.Name, redact.Error(err)))
+				errorLabel.Show()
+				return
+			}
+			newProfiles = newProfiles[1:]
+		}
+		window.Close()
+	})
+	importButton.Importance = widget.HighImportance
+	if len(newProfiles) == 0 {
+		importButton.Disable()
+	}
+
+	window.SetContent(container.NewBorder(
+		widget.NewLabel("链接请求导入以下配置，确认后才会保存:"),
+		container.NewVBox(errorLabel, container.NewGridWithColumns(2,
+			widget.NewButton("取消", func() { window.Close() }),
+			importButton,
+		)),
+		nil, nil,
+		container.NewVScroll(rows),
+	))
+	window.Show()
+}
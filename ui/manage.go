@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// ManagementWindow lists the profiles and lets the user activate, delete
+// and add them
+type ManagementWindow struct {
+	window        fyne.Window
+	configManager *config.Manager
+	onAdd         func() // Opens the add profile dialog
+	message       *widget.Label
+}
+
+// NewManagementWindow uses window to show the profile management view
+func NewManagementWindow(window fyne.Window, configManager *config.Manager, onAdd func()) *ManagementWindow {
+	window.Resize(fyne.NewSize(520, 360))
+
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+	message.Hide()
+
+	w := &ManagementWindow{
+		window:        window,
+		configManager: configManager,
+		onAdd:         onAdd,
+		message:       message,
+	}
+	w.Refresh()
+	return w
+}
+
+// Show refreshes the window and brings it to the front
+func (w *ManagementWindow) Show() {
+	w.Refresh()
+	w.window.Show()
+	w.window.RequestFocus()
+}
+
+// Refresh rebuilds the view from the current settings
+func (w *ManagementWindow) Refresh() {
+	settings := w.configManager.GetSettings()
+
+	enabledCheck := widget.NewCheck("启用", nil)
+	enabledCheck.SetChecked(settings.Enabled)
+	enabledCheck.OnChanged = func(enabled bool) {
+		w.apply(w.configManager.SetEnabled(enabled), "更新启用状态失败")
+	}
+
+	rows := container.NewVBox()
+	if len(settings.Profiles) == 0 {
+		rows.Add(widget.NewLabel("暂无配置文件"))
+	}
+	for _, profile := range settings.Profiles {
+		p := profile // capture for closure
+
+		label := widget.NewLabel(fmt.Sprintf("%s  (%s)", p.Name, redact.String(p.APIURL)))
+		activateButton := widget.NewButton("切换", func() {
+			// Hooks run in the background so they never block the window
+			go func() {
+				err := config.SwitchProfile(w.withManager, p.Name)
+				fyne.Do(func() {
+					if errors.Is(err, config.ErrPostSwitchHook) {
+						w.apply(err, "已切换，但切换后钩子执行失败")
+						return
+					}
+					w.apply(err, "设置活动配置失败")
+				})
+			}()
+		})
+		if p.Active {
+			label.TextStyle = fyne.TextStyle{Bold: true}
+			activateButton.SetText("当前")
+			activateButton.Disable()
+		}
+
+		deleteButton := widget.NewButton("删除", func() {
+			dialog.ShowConfirm("删除配置", fmt.Sprintf("确定删除配置 '%s' 吗？", p.Name), func(ok bool) {
+				if ok {
+					w.apply(w.configManager.DeleteProfile(p.Name), "删除配置失败")
+				}
+			}, w.window)
+		})
+		deleteButton.Importance = widget.DangerImportance
+
+		rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(activateButton, deleteButton), label))
+	}
+
+	w.window.SetContent(container.NewBorder(
+		container.NewVBox(enabledCheck, widget.NewSeparator()),
+		container.NewVBox(w.message, widget.NewSeparator(), widget.NewButton("添加新配置", w.onAdd)),
+		nil, nil,
+		container.NewVScroll(rows),
+	))
+}
+
+// withManager runs fn on the UI thread, where the config manager is used.
+// It must not be called from the UI thread itself.
+func (w *ManagementWindow) withManager(fn func(m *config.Manager) error) error {
+	var err error
+	fyne.DoAndWait(func() {
+		err = fn(w.configManager)
+	})
+	return err
+}
+
+// apply reports the outcome of an action and refreshes the window
+func (w *ManagementWindow) apply(err error, action string) {
+	if err != nil {
+		w.message.SetText(fmt.Sprintf("%s: %v", action, redact.Error(err)))
+		w.message.Show()
+	} else {
+		w.message.Hide()
+	}
+
+	w.Refresh()
+}
@@ -3,7 +3,6 @@ package ui
 
 import (
 	"fmt"
-	"net/url"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -12,24 +11,12 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/ipfans/cc-quick-profile/config"
 	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
 )
 
-// EventType represents UI events
-type EventType string
-
-const (
-	// EventConfigUpdated is sent when configuration has been updated
-	EventConfigUpdated EventType = "config_updated"
-)
-
-// Event represents a UI event
-type Event struct {
-	Type EventType
-	Data interface{}
-}
-
-// ShowAddProfileModal displays the add profile dialog
-func ShowAddProfileModal(app fyne.App, configManager *config.Manager, eventChan chan<- Event) {
+// ShowAddProfileModal displays the add profile dialog. The manager announces
+// the new profile on its event bus.
+func ShowAddProfileModal(app fyne.App, configManager *config.Manager) {
 	window := app.NewWindow("添加新配置")
 	window.Resize(fyne.NewSize(400, 300))
 	window.CenterOnScreen()
@@ -49,64 +36,37 @@ func ShowAddProfileModal(app fyne.App, configManager *config.Manager, eventChan
 	errorLabel.Hide()
 	errorLabel.TextStyle = fyne.TextStyle{Bold: true}
 
-	// Validate input
-	validate := func() error {
-		// Clear previous error
-		errorLabel.Hide()
-		errorLabel.SetText("")
-
-		// Check name
-		name := strings.TrimSpace(nameEntry.Text)
-		if name == "" {
-			return fmt.Errorf("配置名称不能为空")
-		}
-
-		// Check API URL
-		apiURL := strings.TrimSpace(apiURLEntry.Text)
-		if apiURL == "" {
-			return fmt.Errorf("API URL 不能为空")
-		}
-
-		// Validate URL format
-		if _, err := url.Parse(apiURL); err != nil {
-			return fmt.Errorf("API URL 格式无效")
-		}
-
-		// Check API key
-		apiKey := strings.TrimSpace(apiKeyEntry.Text)
-		if apiKey == "" {
-			return fmt.Errorf("API 密钥不能为空")
+	// Build the profile from the trimmed form fields
+	formProfile := func() models.Profile {
+		return models.Profile{
+			Name:   strings.TrimSpace(nameEntry.Text),
+			APIURL: strings.TrimSpace(apiURLEntry.Text),
+			APIKey: strings.TrimSpace(apiKeyEntry.Text),
+			Active: false,
 		}
-
-		return nil
 	}
 
 	// Save button handler
 	onSave := func() {
-		if err := validate(); err != nil {
+		// Clear previous error
+		errorLabel.Hide()
+		errorLabel.SetText("")
+
+		// Validate input
+		profile := formProfile()
+		if err := profile.Validate(); err != nil {
 			errorLabel.SetText(err.Error())
 			errorLabel.Show()
 			return
 		}
 
-		// Create new profile
-		profile := models.Profile{
-			Name:   strings.TrimSpace(nameEntry.Text),
-			APIURL: strings.TrimSpace(apiURLEntry.Text),
-			APIKey: strings.TrimSpace(apiKeyEntry.Text),
-			Active: false,
-		}
-
 		// Add to config
 		if err := configManager.AddProfile(profile); err != nil {
-			errorLabel.SetText(fmt.Sprintf("保存失败: %v", err))
+			errorLabel.SetText(fmt.Sprintf("保存失败: %v", redact.Error(err)))
 			errorLabel.Show()
 			return
 		}
 
-		// Send update event
-		eventChan <- Event{Type: EventConfigUpdated}
-
 		// Show success and close
 		dialog.ShowInformation("成功",
 			fmt.Sprintf("配置 '%s' 已成功添加。", profile.Name),
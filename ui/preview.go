@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ipfans/cc-quick-profile/claude"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/diff"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// ShowSwitchPreview shows the changes activating a profile would make to
+// Claude Code's settings, with secrets masked, and calls onConfirm if the
+// user chooses to switch
+func ShowSwitchPreview(app fyne.App, configManager *config.Manager, name string, onConfirm func()) {
+	window := app.NewWindow(fmt.Sprintf("切换到配置: %s", name))
+	window.Resize(fyne.NewSize(640, 400))
+	window.CenterOnScreen()
+
+	text := "Claude Code 设置无需更改。"
+	before, after, err := configManager.PreviewActivation(name)
+	if err != nil {
+		text = fmt.Sprintf("生成预览失败: %v", redact.Error(err))
+	} else {
+		path := configManager.ClaudeSettingsPath()
+		if changes := diff.Unified(path, path, before, after); changes != "" {
+			text = redact.String(claude.SecretMasker(before, after).Replace(changes))
+		}
+		if !configManager.GetSettings().Enabled {
+			text = "当前处于禁用状态，切换配置不会修改 Claude Code 设置。"
+		}
+	}
+
+	preview := widget.NewLabel(text)
+	preview.TextStyle = fyne.TextStyle{Monospace: true}
+
+	confirmButton := widget.NewButton("切换", func() {
+		window.Close()
+		onConfirm()
+	})
+	confirmButton.Importance = widget.HighImportance
+	if err != nil {
+		confirmButton.Disable()
+	}
+
+	window.SetContent(container.NewBorder(
+		widget.NewLabel("将对 Claude Code 设置做出以下更改:"),
+		container.NewGridWithColumns(2,
+			widget.NewButton("取消", func() { window.Close() }),
+			confirmButton,
+		),
+		nil, nil,
+		container.NewScroll(preview),
+	))
+	window.Show()
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ipfans/cc-quick-profile/config"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+// ShowWipeWindow asks for confirmation, wipes every deployed credential and
+// shows a report of each cleaned location. onDone is called after the wipe.
+// with must run fn on the UI thread, where the config manager is used; the
+// files are cleaned in the background so the window stays responsive.
+func ShowWipeWindow(app fyne.App, with func(fn func(m *config.Manager) error) error, onDone func()) {
+	window := app.NewWindow("紧急清除凭据")
+	window.Resize(fyne.NewSize(560, 320))
+	window.CenterOnScreen()
+
+	warning := widget.NewLabel("此操作将从所有已写入的位置移除 API 凭据，并删除全部已保存的配置。\n此操作无法撤销。")
+	warning.Wrapping = fyne.TextWrapWord
+	warning.TextStyle = fyne.TextStyle{Bold: true}
+
+	var confirmButton *widget.Button
+	showReport := func(results []config.WipeResult) {
+		lines := []string{}
+		for _, result := range results {
+			if result.Err != nil {
+				lines = append(lines, fmt.Sprintf("✗ %s: %v", result.Path, redact.Error(result.Err)))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("✓ %s: %s", result.Path, result.Detail))
+		}
+
+		report := widget.NewLabel(strings.Join(lines, "\n"))
+		report.Wrapping = fyne.TextWrapWord
+
+		window.SetContent(container.NewBorder(
+			widget.NewLabel("清除报告"),
+			widget.NewButton("关闭", func() { window.Close() }),
+			nil, nil,
+			container.NewVScroll(report),
+		))
+
+		if onDone != nil {
+			onDone()
+		}
+	}
+
+	onConfirm := func() {
+		confirmButton.Disable()
+		confirmButton.SetText("正在清除…")
+		go func() {
+			results := config.WipeCredentials(with)
+			fyne.Do(func() {
+				showReport(results)
+			})
+		}()
+	}
+
+	confirmButton = widget.NewButton("立即清除", onConfirm)
+	confirmButton.Importance = widget.DangerImportance
+
+	window.SetContent(container.NewPadded(container.NewVBox(
+		warning,
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			widget.NewButton("取消", func() { window.Close() }),
+			confirmButton,
+		),
+	)))
+	window.Show()
+}
@@ -0,0 +1,69 @@
+// Package webhook notifies configured URLs of profile changes. Deliveries
+// are queued on disk so they survive restarts and are retried until the
+// target accepts them.
+package webhook
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/events"
+)
+
+// Event names used in payloads and in models.Webhook.Events
+const (
+	EventSwitch = "switch"
+	EventAdd    = "add"
+	EventDelete = "delete"
+)
+
+// Payload is the JSON body posted to webhooks. It names profiles but never
+// carries keys or URLs.
+type Payload struct {
+	Event   string    `json:"event"`   // One of the Event constants
+	Profile string    `json:"profile"` // The profile concerned
+	User    string    `json:"user"`    // Who made the change
+	Host    string    `json:"host"`    // Where the change was made
+	Time    time.Time `json:"time"`    // When the change was made
+	Text    string    `json:"text"`    // Summary for chat services such as Slack
+}
+
+// NewPayload describes a bus event, returning false for events that are
+// not sent to webhooks
+func NewPayload(e events.Event, now time.Time) (Payload, bool) {
+	p := Payload{User: currentUser(), Host: hostname(), Time: now.UTC()}
+
+	switch e := e.(type) {
+	case events.ProfileActivated:
+		p.Event, p.Profile = EventSwitch, e.Name
+		p.Text = fmt.Sprintf("%s@%s 切换到配置 %s", p.User, p.Host, e.Name)
+	case events.ProfileAdded:
+		p.Event, p.Profile = EventAdd, e.Name
+		p.Text = fmt.Sprintf("%s@%s 添加了配置 %s", p.User, p.Host, e.Name)
+	case events.ProfileDeleted:
+		p.Event, p.Profile = EventDelete, e.Name
+		p.Text = fmt.Sprintf("%s@%s 删除了配置 %s", p.User, p.Host, e.Name)
+	default:
+		return Payload{}, false
+	}
+
+	return p, true
+}
+
+// currentUser returns the login name, or "unknown"
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// hostname returns the machine name, or "unknown"
+func hostname() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,310 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+	"github.com/ipfans/cc-quick-profile/redact"
+)
+
+const (
+	// maxAttempts is how often a delivery is tried before it is dropped
+	maxAttempts = 10
+	// firstRetry is the delay after the first failure; it doubles each time
+	firstRetry = 30 * time.Second
+	// maxRetry caps the delay between attempts
+	maxRetry = time.Hour
+	// staleClaim is how long a delivery may be claimed before it is assumed
+	// its sender died and it is queued again
+	staleClaim = 10 * time.Minute
+	// requestTimeout bounds a single POST
+	requestTimeout = 10 * time.Second
+
+	queueSuffix     = ".json"
+	claimSuffix     = ".sending"
+	signatureHeader = "X-CC-Quick-Profile-Signature-256"
+	eventHeader     = "X-CC-Quick-Profile-Event"
+	deliveryHeader  = "X-CC-Quick-Profile-Delivery"
+)
+
+// Queue stores pending deliveries as one file each in a directory. Several
+// processes may share it: a delivery is claimed by renaming its file.
+type Queue struct {
+	dir      string
+	client   *http.Client
+	enqueued atomic.Int64 // Deliveries written through this queue
+}
+
+// delivery is a queued POST. The signing secret is looked up from the
+// settings when it is sent, so it is never written to the queue.
+type delivery struct {
+	ID          string          `json:"id"`
+	URL         string          `json:"url"`
+	Event       string          `json:"event"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+}
+
+// NewQueue creates a queue storing its files in dir
+func NewQueue(dir string) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+	return &Queue{dir: dir, client: &http.Client{Timeout: requestTimeout}}, nil
+}
+
+// Enqueue queues the payload for every webhook subscribed to its event
+func (q *Queue) Enqueue(webhooks []models.Webhook, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	for _, w := range webhooks {
+		if !w.Wants(p.Event) {
+			continue
+		}
+
+		d := delivery{ID: newID(), URL: w.URL, Event: p.Event, Body: body}
+		name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), d.ID, queueSuffix)
+		if err := q.write(filepath.Join(q.dir, name), d); err != nil {
+			return err
+		}
+		q.enqueued.Add(1)
+	}
+	return nil
+}
+
+// Enqueued returns how many deliveries were queued through q, not counting
+// those queued by other processes sharing the directory
+func (q *Queue) Enqueued() int {
+	return int(q.enqueued.Load())
+}
+
+// Flush tries every due delivery once. Failed deliveries are rescheduled
+// with a growing delay and dropped after maxAttempts. It returns when the
+// next delivery is due, or the zero time if the queue is empty, together
+// with the failures of this round.
+func (q *Queue) Flush(ctx context.Context, webhooks []models.Webhook) (time.Time, error) {
+	q.releaseStale()
+
+	names, err := q.pending()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	errs := []error{}
+	for _, name := range names {
+		if ctx.Err() != nil {
+			break
+		}
+
+		due, err := q.process(ctx, filepath.Join(q.dir, name), webhooks)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !due.IsZero() && (next.IsZero() || due.Before(next)) {
+			next = due
+		}
+	}
+
+	return next, errors.Join(errs...)
+}
+
+// process sends one delivery if it is due. It returns when the delivery is
+// due next, or the zero time once it has been sent or dropped.
+func (q *Queue) process(ctx context.Context, path string, webhooks []models.Webhook) (time.Time, error) {
+	d, err := q.read(path)
+	if err != nil {
+		os.Remove(path)
+		return time.Time{}, fmt.Errorf("dropped unreadable webhook delivery %s: %w", filepath.Base(path), err)
+	}
+	if time.Now().Before(d.NextAttempt) {
+		return d.NextAttempt, nil
+	}
+
+	target := findWebhook(webhooks, d.URL)
+	if target == nil {
+		os.Remove(path)
+		return time.Time{}, nil // The webhook was removed from the settings
+	}
+
+	// Another process may be sending the same file
+	claimed := path + claimSuffix
+	if err := os.Rename(path, claimed); err != nil {
+		return time.Time{}, nil
+	}
+
+	sendErr := q.send(ctx, target, d)
+	if sendErr == nil {
+		os.Remove(claimed)
+		return time.Time{}, nil
+	}
+
+	d.Attempts++
+	if d.Attempts >= maxAttempts {
+		os.Remove(claimed)
+		return time.Time{}, fmt.Errorf("dropped webhook delivery to %s after %d attempts: %w", webhookHost(d.URL), d.Attempts, sendErr)
+	}
+
+	d.NextAttempt = time.Now().Add(retryDelay(d.Attempts))
+	if err := q.write(path, d); err != nil {
+		os.Rename(claimed, path)
+		return time.Time{}, err
+	}
+	os.Remove(claimed)
+	return d.NextAttempt, fmt.Errorf("webhook delivery to %s failed, retrying at %s: %w",
+		webhookHost(d.URL), d.NextAttempt.Format(time.TimeOnly), sendErr)
+}
+
+// send posts the delivery, signing it if the webhook has a secret
+func (q *Queue) send(ctx context.Context, w *models.Webhook, d delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cc-quick-profile")
+	req.Header.Set(eventHeader, d.Event)
+	req.Header.Set(deliveryHeader, d.ID)
+	if w.Secret != "" {
+		req.Header.Set(signatureHeader, Sign(w.Secret, d.Body))
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		// The client's error repeats the URL, whose path often holds a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return redact.Error(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookHost returns the host of a webhook URL for log messages. The path
+// and query are left out because services such as Slack put the secret there.
+func webhookHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "invalid URL"
+	}
+	return u.Host
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// pending returns the queued file names, oldest first
+func (q *Queue) pending() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook queue: %w", err)
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), queueSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// releaseStale queues deliveries again whose sender stopped while sending
+func (q *Queue) releaseStale() {
+	claims, _ := filepath.Glob(filepath.Join(q.dir, "*"+queueSuffix+claimSuffix))
+	for _, claimed := range claims {
+		info, err := os.Stat(claimed)
+		if err == nil && time.Since(info.ModTime()) > staleClaim {
+			os.Rename(claimed, strings.TrimSuffix(claimed, claimSuffix))
+		}
+	}
+}
+
+// read loads a queued delivery
+func (q *Queue) read(path string) (delivery, error) {
+	var d delivery
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return d, err
+	}
+	err = json.Unmarshal(data, &d)
+	return d, err
+}
+
+// write stores a delivery atomically, readable only by the user
+func (q *Queue) write(path string, d delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// retryDelay returns the wait after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetry)
+}
+
+// findWebhook returns the configured webhook with the given URL, or nil
+func findWebhook(webhooks []models.Webhook, url string) *models.Webhook {
+	for i := range webhooks {
+		if webhooks[i].URL == url {
+			return &webhooks[i]
+		}
+	}
+	return nil
+}
+
+// newID returns a random delivery identifier
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret string
+		body   string
+		want   string
+	}{
+		{"key", "The quick brown fox jumps over the lazy dog", "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", tt.secret, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{maxAttempts, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// recorder is a webhook target that answers with status and keeps what it
+// received
+type recorder struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	w.WriteHeader(r.status)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// queued returns the number of files left in the queue directory
+func queued(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestQueueDelivers(t *testing.T) {
+	target := &recorder{status: http.StatusNoContent}
+	server := httptest.NewServer(target)
+	defer server.Close()
+	other := &recorder{status: http.StatusNoContent}
+	otherServer := httptest.NewServer(other)
+	defer otherServer.Close()
+
+	webhooks := []models.Webhook{
+		{URL: server.URL, Secret: "s3cret"},
+		{URL: otherServer.URL, Events: []string{EventAdd}},
+	}
+	dir := t.TempDir()
+	q, err := NewQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(webhooks, Payload{Event: EventSwitch, Profile: "work"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if got := q.Enqueued(); got != 1 {
+		t.Errorf("Enqueued() = %d, want 1 for the one subscribed webhook", got)
+	}
+
+	next, err := q.Flush(context.Background(), webhooks)
+	if err != nil || !next.IsZero() {
+		t.Fatalf("Flush() = %v, %v, want nothing left", next, err)
+	}
+	if target.count() != 1 || other.count() != 0 {
+		t.Fatalf("deliveries = %d and %d, want 1 and 0", target.count(), other.count())
+	}
+	req, body := target.requests[0], target.bodies[0]
+	if got := req.Header.Get(signatureHeader); got != Sign("s3cret", []byte(body)) {
+		t.Errorf("signature = %q, want the HMAC of the body", got)
+	}
+	if got := req.Header.Get(eventHeader); got != EventSwitch {
+		t.Errorf("event header = %q, want %q", got, EventSwitch)
+	}
+	if req.Header.Get(deliveryHeader) == "" {
+		t.Error("delivery header is empty")
+	}
+	if n := queued(t, dir); n != 0 {
+		t.Errorf("%d files left in the queue, want 0", n)
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	target := &recorder{status: http.StatusInternalServerError}
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	// Chat services put the secret in the path, which must not be logged
+	const token = "T000-hook-path-secret"
+	webhooks := []models.Webhook{{URL: server.URL + "/services/" + token}}
+	dir := t.TempDir()
+	q, err := NewQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(webhooks, Payload{Event: EventDelete, Profile: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	next, err := q.Flush(context.Background(), webhooks)
+	if err == nil {
+		t.Error("Flush() error = nil, want the failed delivery")
+	} else if strings.Contains(err.Error(), token) {
+		t.Errorf("Flush() error = %v, want the URL path left out", err)
+	}
+	if next.Before(start.Add(firstRetry)) || next.After(time.Now().Add(firstRetry)) {
+		t.Errorf("Flush() next = %v, want about %v from now", next, firstRetry)
+	}
+
+	// The delivery is not due yet, so it is neither sent nor dropped
+	if again, err := q.Flush(context.Background(), webhooks); err != nil || !again.Equal(next) {
+		t.Errorf("second Flush() = %v, %v, want %v and no error", again, err, next)
+	}
+	if target.count() != 1 {
+		t.Errorf("deliveries = %d, want 1", target.count())
+	}
+	if n := queued(t, dir); n != 1 {
+		t.Errorf("%d files in the queue, want 1", n)
+	}
+}
+
+func TestQueueDropsRemovedWebhook(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue([]models.Webhook{{URL: "http://127.0.0.1:1/hook"}}, Payload{Event: EventAdd, Profile: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := q.Flush(context.Background(), nil)
+	if err != nil || !next.IsZero() {
+		t.Errorf("Flush() = %v, %v, want nothing left", next, err)
+	}
+	if n := queued(t, dir); n != 0 {
+		t.Errorf("%d files left in the queue, want 0", n)
+	}
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfans/cc-quick-profile/models"
+)
+
+// idleRecheck is how often an empty queue is checked for deliveries queued
+// by other processes
+const idleRecheck = 5 * time.Minute
+
+// Sender delivers queued webhooks in the background
+type Sender struct {
+	queue    *Queue
+	webhooks func() []models.Webhook // Returns the configured webhooks; must be safe to call from any goroutine
+	report   func(error)             // Receives delivery failures
+	wake     chan struct{}
+}
+
+// NewSender creates a sender for queue
+func NewSender(queue *Queue, webhooks func() []models.Webhook, report func(error)) *Sender {
+	return &Sender{queue: queue, webhooks: webhooks, report: report, wake: make(chan struct{}, 1)}
+}
+
+// Wake makes the sender check the queue now, such as after an event was queued
+func (s *Sender) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers deliveries as they become due until ctx is cancelled
+func (s *Sender) Run(ctx context.Context) {
+	for {
+		wait := idleRecheck
+		webhooks := s.webhooks()
+		if len(webhooks) > 0 {
+			next, err := s.queue.Flush(ctx, webhooks)
+			if err != nil && ctx.Err() == nil {
+				s.report(err)
+			}
+			if !next.IsZero() {
+				wait = min(wait, time.Until(next))
+			}
+		}
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}